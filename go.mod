@@ -3,8 +3,10 @@ module quickdev
 go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/fsnotify/fsnotify v1.7.0
 	github.com/fatih/color v1.16.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"quickdev/internal/types"
@@ -18,166 +19,256 @@ const (
 	IgnoreFileName = ".quickdevignore"
 )
 
-// LoadConfig loads and merges configuration from various sources
-func LoadConfig(cliConfig *types.FileWatcherConfig, projectRoot string) (*types.FileWatcherConfig, error) {
-	// Try to load config file
-	fileConfig, err := loadConfigFile(projectRoot)
-	if err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("error loading config file: %v", err)
+// LoadConfig resolves the configuration from its layers: defaults, the
+// config file, QUICKDEV_* environment variables and the command line
+// flags that were actually set, each overriding the previous one key by
+// key. The config file is looked up in startDir and its parents; when one
+// is found its directory becomes the project root.
+func LoadConfig(defaults, cli []Setting, startDir string) (*types.FileWatcherConfig, error) {
+	projectRoot := startDir
+	if absRoot, err := filepath.Abs(projectRoot); err == nil {
+		projectRoot = absRoot
 	}
 
-	// Merge configs with CLI taking precedence
-	finalConfig := mergeConfigs(fileConfig, cliConfig)
-
-	// Load ignore patterns from .quickdevignore
-	if patterns, err := loadIgnoreFile(finalConfig.CustomIgnoreFile, projectRoot); err == nil {
-		finalConfig.IgnorePaths = append(finalConfig.IgnorePaths, patterns...)
+	defaultValues, err := settingsLayer(LayerDefault, defaults)
+	if err != nil {
+		return nil, err
 	}
-
-	return finalConfig, nil
-}
-
-// loadConfigFile attempts to load configuration from quickdev.config.json or .quickdevrc.json
-func loadConfigFile(projectRoot string) (*types.FileWatcherConfig, error) {
-	configFiles := []string{
-		filepath.Join(projectRoot, "quickdev.config.json"),
-		filepath.Join(projectRoot, ".quickdevrc.json"),
+	cliValues, err := settingsLayer(LayerCLI, cli)
+	if err != nil {
+		return nil, err
+	}
+	envValues, err := envLayer(os.Environ())
+	if err != nil {
+		return nil, err
 	}
 
-	var config types.FileWatcherConfig
-	var foundConfig bool
-
-	for _, configFile := range configFiles {
-		data, err := ioutil.ReadFile(configFile)
+	// Try to load config file
+	fileValues := newLayer(LayerFile)
+	configFile, err := findConfigFile(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	if configFile != "" {
+		fileValues, err = loadConfigFile(configFile)
 		if err != nil {
-			if os.IsNotExist(err) {
-				continue
-			}
-			return nil, err
+			return nil, fmt.Errorf("error loading config file: %v", err)
 		}
+		projectRoot = filepath.Dir(configFile)
+	} else if packageDir := findFileDir(projectRoot, PackageFileName); packageDir != "" {
+		// Without a config file the nearest package.json marks the project
+		projectRoot = packageDir
+	}
 
-		if err := json.Unmarshal(data, &config); err != nil {
-			return nil, fmt.Errorf("error parsing %s: %v", configFile, err)
-		}
+	finalConfig, err := resolve(defaultValues, fileValues, envValues, cliValues)
+	if err != nil {
+		return nil, err
+	}
+	finalConfig.ConfigFile = configFile
+	finalConfig.ProjectRoot = projectRoot
 
-		foundConfig = true
-		break
+	// A script from the config file is relative to it, not to where
+	// quickdev runs
+	if finalConfig.Script != "" && !filepath.IsAbs(finalConfig.Script) {
+		if finalConfig.Origins["script"].Layer == LayerFile {
+			finalConfig.Script = filepath.Join(projectRoot, finalConfig.Script)
+		} else if abs, err := filepath.Abs(finalConfig.Script); err == nil {
+			finalConfig.Script = abs
+		}
 	}
 
-	if !foundConfig {
-		return &types.FileWatcherConfig{}, nil
+	// Fall back to the package.json entry point
+	if finalConfig.Script == "" && finalConfig.Exec == "" {
+		if script := packageMain(projectRoot); script != "" {
+			finalConfig.Script = script
+			finalConfig.Origins["script"] = types.ConfigOrigin{Layer: LayerFile, Source: filepath.Join(projectRoot, "package.json")}
+		}
 	}
 
-	return &config, nil
-}
+	// Compile ignorePatterns up front so a bad pattern fails loudly
+	regexps, err := compileIgnorePatterns(finalConfig.IgnorePatterns)
+	if err != nil {
+		return nil, locateError(finalConfig, err)
+	}
+	finalConfig.IgnoreRegexps = regexps
 
-// loadIgnoreFile loads patterns from .quickdevignore file
-func loadIgnoreFile(customIgnoreFile string, projectRoot string) ([]string, error) {
-	var ignoreFile string
-	if customIgnoreFile != "" {
-		ignoreFile = customIgnoreFile
-	} else {
-		ignoreFile = filepath.Join(projectRoot, ".quickdevignore")
+	if err := validateConfig(finalConfig); err != nil {
+		return nil, locateError(finalConfig, err)
 	}
 
-	data, err := ioutil.ReadFile(ignoreFile)
-	if err != nil {
-		return nil, err
+	// Merge git's ignore rules first so .quickdevignore can override them
+	if finalConfig.UseGitignore {
+		finalConfig.IgnoreRules = append(finalConfig.IgnoreRules, loadGitignoreRules(projectRoot, finalConfig.IgnorePaths)...)
 	}
 
-	var patterns []string
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" && !strings.HasPrefix(line, "#") {
-			patterns = append(patterns, line)
-		}
+	// Load ignore patterns from .quickdevignore, relative to its directory
+	ignoreFile := ignoreFilePath(finalConfig.CustomIgnoreFile, projectRoot)
+	if rules, err := readIgnoreRules(ignoreFile); err == nil {
+		finalConfig.IgnoreRules = append(finalConfig.IgnoreRules, rules...)
 	}
 
-	return patterns, nil
+	finalConfig.ConfigSources = configSources(finalConfig, ignoreFile)
+
+	return finalConfig, nil
 }
 
-// mergeConfigs merges CLI config with file config, with CLI taking precedence
-func mergeConfigs(fileConfig, cliConfig *types.FileWatcherConfig) *types.FileWatcherConfig {
-	// Start with file config
-	result := *fileConfig
+// findConfigFile returns the config source of the closest directory at or
+// above dir that has one, or "" if there is none. A directory with more
+// than one source is an error rather than a guess.
+func findConfigFile(dir string) (string, error) {
+	for {
+		sources := configSourcesIn(dir)
+		switch len(sources) {
+		case 0:
+		case 1:
+			return sources[0], nil
+		default:
+			names := make([]string, len(sources))
+			for i, source := range sources {
+				names[i] = filepath.Base(configSourceName(source))
+			}
+			return "", fmt.Errorf("found more than one config source in %s: %s; keep only one", dir, strings.Join(names, ", "))
+		}
 
-	// Override with CLI values if they're non-zero/non-empty
-	if len(cliConfig.WatchPaths) > 0 && cliConfig.WatchPaths[0] != "" {
-		result.WatchPaths = cliConfig.WatchPaths
-	}
-	if len(cliConfig.IgnorePaths) > 0 && cliConfig.IgnorePaths[0] != "" {
-		result.IgnorePaths = cliConfig.IgnorePaths
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
 	}
+}
 
-	// Special handling for extensions - merge instead of replace if CLI extensions are default
-	defaultExts := []string{".js", ".ts", ".jsx", ".tsx"}
-	isDefaultExtList := func(exts []string) bool {
-		if len(exts) != len(defaultExts) {
-			return false
+// findFileDir returns the closest directory at or above dir containing
+// name, or "" if there is none
+func findFileDir(dir, name string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return dir
 		}
-		for i, ext := range exts {
-			if ext != defaultExts[i] {
-				return false
-			}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
 		}
-		return true
+		dir = parent
+	}
+}
+
+// loadConfigFile reads the top-level keys of a config file into a layer
+func loadConfigFile(configFile string) (*layer, error) {
+	data, err := readConfigData(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", configSourceName(configFile), err)
+	}
+
+	// Reject misspelled keys instead of silently ignoring them
+	if err := checkKeys(configFile, data); err != nil {
+		return nil, err
+	}
+
+	// Decode into the config first so type errors name the key
+	var config types.FileWatcherConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, typeError(configFile, err)
 	}
 
-	// Only override extensions if CLI provided non-default extensions
-	if len(cliConfig.Extensions) > 0 && !isDefaultExtList(cliConfig.Extensions) {
-		result.Extensions = cliConfig.Extensions
-	} else if len(result.Extensions) == 0 {
-		// If no extensions in file config, use defaults
-		result.Extensions = defaultExts
+	l := newLayer(LayerFile)
+	if err := json.Unmarshal(data, &l.values); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", configSourceName(configFile), err)
 	}
-	// Otherwise keep the file config extensions
+	for key := range l.values {
+		l.sources[key] = configSourceName(configFile)
+	}
+	return l, nil
+}
 
-	if cliConfig.GracefulShutdownTimeout > 0 {
-		result.GracefulShutdownTimeout = cliConfig.GracefulShutdownTimeout
+// packageMain returns the absolute path of the "main" entry in the
+// package.json of dir, or "" if there is none
+func packageMain(dir string) string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return ""
 	}
-	if cliConfig.MaxRestarts > 0 {
-		result.MaxRestarts = cliConfig.MaxRestarts
+
+	var pkg struct {
+		Main string `json:"main"`
 	}
-	if cliConfig.ResetRestartsAfter > 0 {
-		result.ResetRestartsAfter = cliConfig.ResetRestartsAfter
+	if err := json.Unmarshal(data, &pkg); err != nil || pkg.Main == "" {
+		return ""
 	}
-	if cliConfig.RestartDelay > 0 {
-		result.RestartDelay = cliConfig.RestartDelay
+
+	script := filepath.Join(dir, pkg.Main)
+	if _, err := os.Stat(script); err != nil {
+		return ""
 	}
-	if cliConfig.BatchTimeout > 0 {
-		result.BatchTimeout = cliConfig.BatchTimeout
+	return script
+}
+
+// compileIgnorePatterns compiles the ignorePatterns regular expressions
+func compileIgnorePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, &keyError{path: indexPath("ignorePatterns", i), err: fmt.Errorf("invalid regular expression in ignorePatterns[%d] %q: %v", i, pattern, err)}
+		}
+		regexps = append(regexps, re)
 	}
-	if cliConfig.PollingInterval > 0 {
-		result.PollingInterval = cliConfig.PollingInterval
+	return regexps, nil
+}
+
+// ignoreFilePath returns the ignore file to read: the custom one,
+// relative to the project root, or .quickdevignore
+func ignoreFilePath(customIgnoreFile string, projectRoot string) string {
+	if customIgnoreFile == "" {
+		return filepath.Join(projectRoot, IgnoreFileName)
 	}
-	if cliConfig.DebounceMs > 0 {
-		result.DebounceMs = cliConfig.DebounceMs
+	if filepath.IsAbs(customIgnoreFile) {
+		return customIgnoreFile
 	}
-	if cliConfig.MaxFileSize > 0 {
-		result.MaxFileSize = cliConfig.MaxFileSize
+	return filepath.Join(projectRoot, customIgnoreFile)
+}
+
+// configSources lists the files that change cfg when edited: the config
+// file, the ones that would be picked up if created in the project root,
+// and the ignore files read from the project root. Nested .gitignore
+// files are not included.
+func configSources(cfg *types.FileWatcherConfig, ignoreFile string) []string {
+	var sources []string
+	if cfg.ConfigFile != "" {
+		sources = append(sources, cfg.ConfigFile)
 	}
-	if cliConfig.HealthCheckInterval > 0 {
-		result.HealthCheckInterval = cliConfig.HealthCheckInterval
+	for _, name := range configFileNames {
+		path := filepath.Join(cfg.ProjectRoot, name)
+		if name != PackageFileName && path != cfg.ConfigFile {
+			sources = append(sources, path)
+		}
 	}
-	if cliConfig.MemoryLimit > 0 {
-		result.MemoryLimit = cliConfig.MemoryLimit
+
+	sources = append(sources, ignoreFile)
+	if cfg.UseGitignore {
+		sources = append(sources, filepath.Join(cfg.ProjectRoot, GitignoreFileName))
 	}
-	if cliConfig.CustomIgnoreFile != "" {
-		result.CustomIgnoreFile = cliConfig.CustomIgnoreFile
+	return sources
+}
+
+// readIgnoreRules reads a gitignore-style file; its patterns are relative
+// to the directory containing it
+func readIgnoreRules(ignoreFile string) ([]types.IgnoreRule, error) {
+	data, err := ioutil.ReadFile(ignoreFile)
+	if err != nil {
+		return nil, err
 	}
 
-	// Boolean flags
-	result.GracefulShutdown = cliConfig.GracefulShutdown
-	result.BatchChanges = cliConfig.BatchChanges
-	result.EnableFileHashing = cliConfig.EnableFileHashing
-	result.UsePolling = cliConfig.UsePolling
-	result.FollowSymlinks = cliConfig.FollowSymlinks
-	result.WatchDotFiles = cliConfig.WatchDotFiles
-	result.ParallelProcessing = cliConfig.ParallelProcessing
-	result.ExcludeEmptyFiles = cliConfig.ExcludeEmptyFiles
-	result.HealthCheck = cliConfig.HealthCheck
-	result.ClearScreen = cliConfig.ClearScreen
+	base := filepath.Dir(ignoreFile)
+	var rules []types.IgnoreRule
+	lines := strings.Split(string(data), "\n")
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#") {
+			rules = append(rules, types.IgnoreRule{Pattern: line, Base: base})
+		}
+	}
 
-	return &result
-}
\ No newline at end of file
+	return rules, nil
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestCompileIgnorePatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		matches  []string
+		wantErr  string
+	}{
+		{nil, nil, ""},
+		{[]string{`\.spec\.ts$`, `^src/generated/`}, []string{"src/app.spec.ts", "src/generated/"}, ""},
+		{[]string{`\.spec\.ts$`, `(unclosed`}, nil,
+			"invalid regular expression in ignorePatterns[1] \"(unclosed\": error parsing regexp: missing closing ): `(unclosed`"},
+	}
+
+	for _, tt := range tests {
+		regexps, err := compileIgnorePatterns(tt.patterns)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%q: error = %v, want %q", tt.patterns, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.patterns, err)
+			continue
+		}
+		if len(regexps) != len(tt.patterns) {
+			t.Errorf("%q: compiled %d patterns, want %d", tt.patterns, len(regexps), len(tt.patterns))
+			continue
+		}
+		for i, path := range tt.matches {
+			if !regexps[i].MatchString(path) {
+				t.Errorf("%q: pattern %d does not match %s", tt.patterns, i, path)
+			}
+		}
+	}
+}
+
+func TestLoadConfigLocatesBadIgnorePattern(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, ConfigFileName)
+	data := "{\n  \"script\": \"a.js\",\n  \"ignorePatterns\": [\n    \"\\\\.spec\\\\.ts$\",\n    \"[a-\"\n  ]\n}\n"
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(testDefaults, nil, dir)
+	want := file + ":5:5: invalid regular expression in ignorePatterns[1] \"[a-\": error parsing regexp: missing closing ]: `[a-`"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestLoadConfigScript(t *testing.T) {
+	cwdScript, err := filepath.Abs("app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		files      map[string]string
+		startDir   string
+		cli        []Setting
+		wantScript string // relative to the temporary root unless absolute
+		wantSource string
+	}{
+		{"relative to the config file",
+			map[string]string{ConfigFileName: `{"script": "src/server.ts"}`, "src/deep/.keep": ""},
+			"src/deep", nil, "src/server.ts", ConfigFileName},
+		{"absolute in the config file",
+			map[string]string{ConfigFileName: `{"script": ` + strconv.Quote(cwdScript) + `}`},
+			".", nil, cwdScript, ConfigFileName},
+		{"relative to the working directory from the command line",
+			map[string]string{ConfigFileName: `{"script": "src/server.ts"}`},
+			".", []Setting{{Key: "script", Value: "app.js", Source: "-script"}}, cwdScript, "-script"},
+		{"package.json main",
+			map[string]string{PackageFileName: `{"main": "lib/index.js"}`, "lib/index.js": "", "lib/sub/.keep": ""},
+			"lib/sub", nil, "lib/index.js", PackageFileName},
+		{"package.json main that does not exist",
+			map[string]string{PackageFileName: `{"main": "lib/index.js"}`},
+			".", nil, "", ""},
+		{"no package.json fallback with exec",
+			map[string]string{ConfigFileName: `{"exec": "go run ."}`, PackageFileName: `{"main": "index.js"}`, "index.js": ""},
+			".", nil, "", ""},
+	}
+
+	for _, tt := range tests {
+		root := t.TempDir()
+		for name, data := range tt.files {
+			path := filepath.Join(root, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cfg, err := LoadConfig(testDefaults, tt.cli, filepath.Join(root, tt.startDir))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		want := tt.wantScript
+		if want != "" && !filepath.IsAbs(want) {
+			want = filepath.Join(root, filepath.FromSlash(want))
+		}
+		if cfg.Script != want {
+			t.Errorf("%s: script = %q, want %q", tt.name, cfg.Script, want)
+		}
+		if source := filepath.Base(cfg.Origins["script"].Source); tt.wantSource != "" && source != tt.wantSource {
+			t.Errorf("%s: script from %q, want %q", tt.name, source, tt.wantSource)
+		}
+	}
+}
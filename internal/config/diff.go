@@ -0,0 +1,41 @@
+package config
+
+import (
+	"reflect"
+
+	"quickdev/internal/types"
+)
+
+// Names Changes uses for what is not a setting of its own
+const (
+	ConfigFileKey  = "config file"
+	IgnoreRulesKey = "ignore files"
+)
+
+// Changes lists the settings that differ between two configurations by
+// JSON key, in declaration order, followed by ConfigFileKey when another
+// config file was loaded and IgnoreRulesKey when the rules read from
+// ignore files differ
+func Changes(old, new *types.FileWatcherConfig) []string {
+	var changed []string
+	oldValue := reflect.ValueOf(*old)
+	newValue := reflect.ValueOf(*new)
+	configType := oldValue.Type()
+	for i := 0; i < configType.NumField(); i++ {
+		key := jsonKey(configType.Field(i))
+		if key == "" {
+			continue
+		}
+		if !reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			changed = append(changed, key)
+		}
+	}
+
+	if old.ConfigFile != new.ConfigFile {
+		changed = append(changed, ConfigFileKey)
+	}
+	if !reflect.DeepEqual(old.IgnoreRules, new.IgnoreRules) {
+		changed = append(changed, IgnoreRulesKey)
+	}
+	return changed
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+func TestChanges(t *testing.T) {
+	base := types.FileWatcherConfig{
+		Script:      "/p/app.js",
+		IgnorePaths: []string{"node_modules", "dist"},
+		DebounceMs:  100,
+		Runners:     map[string]string{".py": "python3"},
+		HealthProbe: &types.HealthProbe{Type: types.ProbeTCP, Address: "localhost:3000"},
+		ConfigFile:  "/p/quickdev.config.json",
+		IgnoreRules: []types.IgnoreRule{{Pattern: "*.log", Base: "/p"}},
+	}
+
+	tests := []struct {
+		name   string
+		change func(cfg *types.FileWatcherConfig)
+		want   []string
+	}{
+		{"nothing", func(cfg *types.FileWatcherConfig) {}, nil},
+		{"settings in declaration order", func(cfg *types.FileWatcherConfig) {
+			cfg.DebounceMs = 200
+			cfg.Script = "/p/server.js"
+		}, []string{"script", "debounceMs"}},
+		{"list order", func(cfg *types.FileWatcherConfig) {
+			cfg.IgnorePaths = []string{"dist", "node_modules"}
+		}, []string{"ignore"}},
+		{"map entry", func(cfg *types.FileWatcherConfig) {
+			cfg.Runners = map[string]string{".py": "python3 -u {script}"}
+		}, []string{"runners"}},
+		{"same probe in a new value", func(cfg *types.FileWatcherConfig) {
+			cfg.HealthProbe = &types.HealthProbe{Type: types.ProbeTCP, Address: "localhost:3000"}
+		}, nil},
+		{"probe field", func(cfg *types.FileWatcherConfig) {
+			cfg.HealthProbe = &types.HealthProbe{Type: types.ProbeTCP, Address: "localhost:4000"}
+		}, []string{"healthProbe"}},
+		{"config file", func(cfg *types.FileWatcherConfig) {
+			cfg.ConfigFile = "/p/quickdev.config.yaml"
+		}, []string{ConfigFileKey}},
+		{"ignore file rules", func(cfg *types.FileWatcherConfig) {
+			cfg.IgnoreRules = append(cfg.IgnoreRules, types.IgnoreRule{Pattern: "tmp/", Base: "/p"})
+		}, []string{IgnoreRulesKey}},
+		{"fields that are not settings", func(cfg *types.FileWatcherConfig) {
+			cfg.ProjectRoot = "/q"
+			cfg.Origins = map[string]types.ConfigOrigin{"script": {Layer: LayerCLI}}
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		next := base
+		tt.change(&next)
+		if got := Changes(&base, &next); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: changes = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
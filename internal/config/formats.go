@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config file names besides the JSON ones
+const (
+	YAMLConfigFileName = "quickdev.config.yaml"
+	YMLConfigFileName  = "quickdev.config.yml"
+	TOMLConfigFileName = "quickdev.config.toml"
+	PackageFileName    = "package.json"
+
+	// PackageConfigKey is the package.json key holding the config
+	PackageConfigKey = "quickdev"
+)
+
+// configFileNames lists the config sources of a directory in lookup order.
+// package.json only counts when it has a "quickdev" key.
+var configFileNames = []string{
+	ConfigFileName,
+	RCFileName,
+	YAMLConfigFileName,
+	YMLConfigFileName,
+	TOMLConfigFileName,
+	PackageFileName,
+}
+
+// configSourcesIn returns the config sources present in dir
+func configSourcesIn(dir string) []string {
+	var sources []string
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if name == PackageFileName && !hasPackageConfig(path) {
+			continue
+		}
+		sources = append(sources, path)
+	}
+	return sources
+}
+
+// hasPackageConfig reports whether a package.json has a "quickdev" key
+func hasPackageConfig(path string) bool {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var pkg map[string]json.RawMessage
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return false
+	}
+	_, ok := pkg[PackageConfigKey]
+	return ok
+}
+
+// configSourceName describes a config source in messages, pointing into
+// package.json at the "quickdev" key
+func configSourceName(path string) string {
+	if filepath.Base(path) == PackageFileName {
+		return path + "#" + PackageConfigKey
+	}
+	return path
+}
+
+// readConfigData reads a config source and returns it as JSON
+func readConfigData(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name := filepath.Base(path); {
+	case name == PackageFileName:
+		return packageConfig(data)
+	case strings.HasSuffix(name, ".yaml"), strings.HasSuffix(name, ".yml"):
+		return yamlToJSON(data)
+	case strings.HasSuffix(name, ".toml"):
+		return tomlToJSON(data)
+	default:
+		return data, nil
+	}
+}
+
+// packageConfig extracts the "quickdev" object of a package.json
+func packageConfig(data []byte) ([]byte, error) {
+	var pkg map[string]json.RawMessage
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+
+	raw := pkg[PackageConfigKey]
+	if trimmed := strings.TrimSpace(string(raw)); !strings.HasPrefix(trimmed, "{") {
+		return nil, fmt.Errorf("%q must be an object", PackageConfigKey)
+	}
+	return raw, nil
+}
+
+// yamlToJSON converts a YAML document to JSON
+func yamlToJSON(data []byte) ([]byte, error) {
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return json.Marshal(values)
+}
+
+// tomlToJSON converts a TOML document to JSON
+func tomlToJSON(data []byte) ([]byte, error) {
+	values := map[string]interface{}{}
+	if _, err := toml.Decode(string(data), &values); err != nil {
+		return nil, err
+	}
+	return json.Marshal(values)
+}
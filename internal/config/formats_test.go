@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+// writeFiles creates files, by slash separated path, under root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{ConfigFileName, `{"script": "src/app.ts", "debounceMs": 250, "ignore": ["dist"], "healthProbe": {"type": "tcp", "address": "localhost:3000"}}`},
+		{RCFileName, `{"script": "src/app.ts", "debounceMs": 250, "ignore": ["dist"], "healthProbe": {"type": "tcp", "address": "localhost:3000"}}`},
+		{YAMLConfigFileName, "script: src/app.ts\ndebounceMs: 250\nignore:\n  - dist\nhealthProbe:\n  type: tcp\n  address: localhost:3000\n"},
+		{YMLConfigFileName, "script: src/app.ts\ndebounceMs: 250\nignore: [dist]\nhealthProbe: {type: tcp, address: \"localhost:3000\"}\n"},
+		{TOMLConfigFileName, "script = \"src/app.ts\"\ndebounceMs = 250\nignore = [\"dist\"]\n\n[healthProbe]\ntype = \"tcp\"\naddress = \"localhost:3000\"\n"},
+		{PackageFileName, `{"name": "app", "main": "index.js", "quickdev": {"script": "src/app.ts", "debounceMs": 250, "ignore": ["dist"], "healthProbe": {"type": "tcp", "address": "localhost:3000"}}}`},
+	}
+
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{tt.name: tt.data, "src/.keep": ""})
+
+		cfg, err := LoadConfig(testDefaults, nil, filepath.Join(root, "src"))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		file := filepath.Join(root, tt.name)
+		if cfg.ConfigFile != file || cfg.ProjectRoot != root {
+			t.Errorf("%s: loaded %s with root %s, want %s with root %s", tt.name, cfg.ConfigFile, cfg.ProjectRoot, file, root)
+		}
+		if want := filepath.Join(root, "src", "app.ts"); cfg.Script != want {
+			t.Errorf("%s: script = %q, want %q", tt.name, cfg.Script, want)
+		}
+		if cfg.DebounceMs != 250 || !reflect.DeepEqual(cfg.IgnorePaths, []string{"dist"}) {
+			t.Errorf("%s: debounceMs %d and ignore %q, want 250 and [dist]", tt.name, cfg.DebounceMs, cfg.IgnorePaths)
+		}
+		wantProbe := &types.HealthProbe{Type: types.ProbeTCP, Address: "localhost:3000"}
+		if !reflect.DeepEqual(cfg.HealthProbe, wantProbe) {
+			t.Errorf("%s: healthProbe = %+v, want %+v", tt.name, cfg.HealthProbe, wantProbe)
+		}
+		if source := cfg.Origins["debounceMs"].Source; source != configSourceName(file) {
+			t.Errorf("%s: debounceMs from %q, want %q", tt.name, source, configSourceName(file))
+		}
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		dir     string
+		want    string
+		wantErr string
+	}{
+		{"nothing", map[string]string{"app/.keep": ""}, "app", "", ""},
+		{"package.json without a quickdev key",
+			map[string]string{PackageFileName: `{"main": "index.js"}`}, ".", "", ""},
+		{"package.json with a quickdev key",
+			map[string]string{PackageFileName: `{"quickdev": {}}`}, ".", PackageFileName, ""},
+		{"closest directory wins",
+			map[string]string{ConfigFileName: "{}", "app/" + YAMLConfigFileName: "", "app/src/.keep": ""},
+			"app/src", "app/" + YAMLConfigFileName, ""},
+		{"parent directory",
+			map[string]string{TOMLConfigFileName: "", "app/src/.keep": ""},
+			"app/src", TOMLConfigFileName, ""},
+		{"a directory named like a config file",
+			map[string]string{ConfigFileName + "/.keep": "", RCFileName: "{}"}, ".", RCFileName, ""},
+		{"more than one source",
+			map[string]string{ConfigFileName: "{}", YMLConfigFileName: "", PackageFileName: `{"quickdev": {}}`},
+			".", "", "found more than one config source in %s: quickdev.config.json, quickdev.config.yml, package.json#quickdev; keep only one"},
+	}
+
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeFiles(t, root, tt.files)
+		dir := filepath.Join(root, filepath.FromSlash(tt.dir))
+
+		got, err := findConfigFile(dir)
+		if tt.wantErr != "" {
+			if want := strings.Replace(tt.wantErr, "%s", dir, 1); err == nil || err.Error() != want {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+
+		want := ""
+		if tt.want != "" {
+			want = filepath.Join(root, filepath.FromSlash(tt.want))
+		}
+		if got != want {
+			t.Errorf("%s: found %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestReadConfigDataErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr string
+	}{
+		{YAMLConfigFileName, "", "{}", ""},
+		{TOMLConfigFileName, "", "{}", ""},
+		{YAMLConfigFileName, "script: [a.js", "", "yaml:"},
+		{TOMLConfigFileName, "script = ", "", "toml:"},
+		{PackageFileName, `{"quickdev": "a.js"}`, "", `"quickdev" must be an object`},
+		{PackageFileName, `{"quickdev": {`, "", "unexpected end of JSON input"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.name)
+		if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := readConfigData(path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s %q: error = %v, want %q", tt.name, tt.data, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: unexpected error: %v", tt.name, tt.data, err)
+			continue
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s %q = %s, want %s", tt.name, tt.data, data, tt.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"quickdev/internal/types"
+)
+
+// GitignoreFileName is the name of git's per-directory ignore file
+const GitignoreFileName = ".gitignore"
+
+// loadGitignoreRules collects the ignore rules git would apply to the
+// project, lowest precedence first: the global excludes file,
+// .git/info/exclude, then every .gitignore from the repository root down
+// through the project tree. Each rule is scoped to the directory of the
+// file it came from.
+func loadGitignoreRules(projectRoot string, ignorePaths []string) []types.IgnoreRule {
+	repoRoot := findRepoRoot(projectRoot)
+
+	var rules []types.IgnoreRule
+
+	// Global and repository excludes apply from the repository root
+	if excludesFile := globalExcludesFile(repoRoot); excludesFile != "" {
+		rules = append(rules, readRulesWithBase(excludesFile, repoRoot)...)
+	}
+	rules = append(rules, readRulesWithBase(filepath.Join(repoRoot, ".git", "info", "exclude"), repoRoot)...)
+
+	// .gitignore files above the project root still apply to it
+	var parents []string
+	for dir := projectRoot; ; dir = filepath.Dir(dir) {
+		if dir != projectRoot {
+			parents = append([]string{dir}, parents...)
+		}
+		if dir == repoRoot || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	for _, dir := range parents {
+		if fileRules, err := readIgnoreRules(filepath.Join(dir, GitignoreFileName)); err == nil {
+			rules = append(rules, fileRules...)
+		}
+	}
+
+	// Walk the project for nested .gitignore files, skipping directories
+	// that are already ignored so node_modules and friends are not scanned
+	skip := make([]types.IgnoreRule, 0, len(ignorePaths))
+	for _, pattern := range ignorePaths {
+		if pattern != "" {
+			skip = append(skip, types.IgnoreRule{Pattern: pattern, Base: projectRoot})
+		}
+	}
+	matcher := NewIgnoreMatcher(append(skip, rules...))
+
+	filepath.Walk(projectRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if path != projectRoot && (info.Name() == ".git" || matcher.Match(path, true)) {
+			return filepath.SkipDir
+		}
+
+		fileRules, err := readIgnoreRules(filepath.Join(path, GitignoreFileName))
+		if err != nil {
+			return nil
+		}
+		rules = append(rules, fileRules...)
+		matcher = NewIgnoreMatcher(append(skip, rules...))
+		return nil
+	})
+
+	return rules
+}
+
+// readRulesWithBase reads an ignore file whose patterns are relative to
+// base rather than to the file's own directory
+func readRulesWithBase(file, base string) []types.IgnoreRule {
+	rules, err := readIgnoreRules(file)
+	if err != nil {
+		return nil
+	}
+	for i := range rules {
+		rules[i].Base = base
+	}
+	return rules
+}
+
+// findRepoRoot returns the closest directory at or above dir containing
+// .git, or dir itself when it is not inside a repository
+func findRepoRoot(dir string) string {
+	for current := dir; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		if filepath.Dir(current) == current {
+			return dir
+		}
+	}
+}
+
+// globalExcludesFile returns git's core.excludesFile, defaulting to
+// $XDG_CONFIG_HOME/git/ignore like git does
+func globalExcludesFile(repoRoot string) string {
+	cmd := exec.Command("git", "config", "--get", "core.excludesFile")
+	cmd.Dir = repoRoot
+	if out, err := cmd.Output(); err == nil {
+		if file := strings.TrimSpace(string(out)); file != "" {
+			return expandHome(file)
+		}
+	}
+
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "git", "ignore")
+	}
+	return ""
+}
+
+// expandHome expands a leading ~ to the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
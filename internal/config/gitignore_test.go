@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadGitignoreRules(t *testing.T) {
+	repo := t.TempDir()
+	project := filepath.Join(repo, "project")
+	files := map[string]string{
+		".git/info/exclude":               "*.secret\n",
+		".gitignore":                      "dist/\n*.log\n",
+		"project/.gitignore":              "/coverage\n",
+		"project/src/.gitignore":          "generated/\n!keep.log\n",
+		"project/node_modules/.gitignore": "*.js\n",
+		"xdg/git/ignore":                  "*.bak\n",
+		"gitconfig":                       "",
+	}
+	for name, data := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Keep the user's own git config out of the test
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(repo, "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(repo, "xdg"))
+
+	m := NewIgnoreMatcher(loadGitignoreRules(project, []string{"node_modules"}))
+
+	tests := []struct {
+		name  string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"rule from a parent .gitignore", "app.log", false, true},
+		{"directory rule from a parent .gitignore", "dist", true, true},
+		{"rule from the project .gitignore", "coverage", true, true},
+		{"anchored rule stays at its base", "src/coverage", true, false},
+		{"rule from a nested .gitignore", "src/generated", true, true},
+		{"nested rule is scoped to its directory", "generated", true, false},
+		{"nested negation", "src/keep.log", false, false},
+		{"negation outside its directory", "keep.log", false, true},
+		{"rule from .git/info/exclude", "key.secret", false, true},
+		{"rule from the global excludes file", "old.bak", false, true},
+		{"file no rule covers", "src/app.js", false, false},
+		{".gitignore inside an ignored directory is not read", "node_modules/lib.js", false, false},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(project, filepath.FromSlash(tt.path))
+		if got := m.Match(path, tt.isDir); got != tt.want {
+			t.Errorf("%s: %s ignored = %v, want %v", tt.name, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFindRepoRoot(t *testing.T) {
+	root := t.TempDir()
+	deep := filepath.Join(root, "repo", "a", "b")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "repo", ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(root, "outside")
+	if err := os.Mkdir(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{deep, filepath.Join(root, "repo")},
+		{filepath.Join(root, "repo"), filepath.Join(root, "repo")},
+		{outside, outside},
+	}
+
+	for _, tt := range tests {
+		if got := findRepoRoot(tt.dir); got != tt.want {
+			t.Errorf("findRepoRoot(%s) = %s, want %s", tt.dir, got, tt.want)
+		}
+	}
+}
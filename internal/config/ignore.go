@@ -0,0 +1,202 @@
+package config
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"quickdev/internal/types"
+)
+
+// IgnoreMatcher matches paths against gitignore-style rules
+type IgnoreMatcher struct {
+	rules []ignorePattern
+}
+
+// ignorePattern is a compiled gitignore pattern
+type ignorePattern struct {
+	base    string // Directory the pattern is relative to, slash separated
+	negate  bool
+	dirOnly bool
+	regex   *regexp.Regexp
+}
+
+// NewIgnoreMatcher compiles rules into a matcher. Rules are evaluated in
+// order and the last matching rule wins, so later rules (for example from
+// a nested ignore file) override earlier ones.
+func NewIgnoreMatcher(rules []types.IgnoreRule) *IgnoreMatcher {
+	m := &IgnoreMatcher{}
+	for _, rule := range rules {
+		if pattern, ok := compileIgnorePattern(rule); ok {
+			m.rules = append(m.rules, pattern)
+		}
+	}
+	return m
+}
+
+// Match reports whether path is ignored. A path is also ignored when one
+// of its parent directories is, and negations cannot re-include a path
+// whose parent directory is excluded, as in git.
+func (m *IgnoreMatcher) Match(path string, isDir bool) bool {
+	if len(m.rules) == 0 {
+		return false
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+
+	// Check parent directories from the top down
+	for i := 1; i < len(path); i++ {
+		if path[i] == '/' && m.matchPath(path[:i], true) {
+			return true
+		}
+	}
+
+	return m.matchPath(path, isDir)
+}
+
+// matchPath applies the rules to a single path without looking at parents
+func (m *IgnoreMatcher) matchPath(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		rel, ok := relativeTo(rule.base, path)
+		if !ok {
+			continue
+		}
+
+		if rule.regex.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// relativeTo returns path relative to base if it lies below it
+func relativeTo(base, path string) (string, bool) {
+	if base == "" || base == "/" {
+		return strings.TrimPrefix(path, "/"), true
+	}
+	if !strings.HasPrefix(path, base+"/") {
+		return "", false
+	}
+	return path[len(base)+1:], true
+}
+
+// compileIgnorePattern parses a single gitignore line
+func compileIgnorePattern(rule types.IgnoreRule) (ignorePattern, bool) {
+	line := strings.TrimRight(rule.Pattern, "\r")
+
+	// Blank lines and comments match nothing
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	pattern := ignorePattern{}
+
+	switch {
+	case strings.HasPrefix(line, "!"):
+		pattern.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, "\\!"), strings.HasPrefix(line, "\\#"):
+		line = line[1:]
+	}
+
+	// Accept Windows separators and the "./" prefix people tend to write
+	if filepath.Separator == '\\' {
+		line = strings.ReplaceAll(line, "\\", "/")
+	}
+	line = strings.TrimPrefix(line, "./")
+
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	// A slash at the start or in the middle anchors the pattern to the base
+	// directory, otherwise it matches at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+
+	regex, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	pattern.regex = regex
+
+	if base, err := filepath.Abs(rule.Base); err == nil && rule.Base != "" {
+		pattern.base = strings.TrimRight(filepath.ToSlash(base), "/")
+	}
+
+	return pattern, true
+}
+
+// globToRegexp converts a gitignore glob to a regular expression
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				atEnd := i+2 == len(glob) || glob[i+2] == '/'
+				if atStart && atEnd {
+					if i+2 == len(glob) {
+						// Trailing "**" matches everything inside
+						expr.WriteString(".*")
+					} else {
+						// "**/" matches zero or more directories
+						expr.WriteString("(?:.*/)?")
+						i++
+					}
+					i++
+					continue
+				}
+			}
+			expr.WriteString("[^/]*")
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString("\\[")
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, "\\", "\\\\") + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return expr.String()
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	root, err := filepath.Abs("project")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"unanchored name at the top", []string{"node_modules"}, "node_modules", true, true},
+		{"unanchored name at any depth", []string{"node_modules"}, "packages/a/node_modules", true, true},
+		{"inside an ignored directory", []string{"node_modules"}, "src/node_modules/lib/index.js", false, true},
+		{"unanchored glob", []string{"*.log"}, "logs/debug.log", false, true},
+		{"glob does not match other names", []string{"*.log"}, "debug.logs", false, false},
+
+		{"leading slash anchors", []string{"/build"}, "build/app.js", false, true},
+		{"leading slash only at the base", []string{"/build"}, "src/build/app.js", false, false},
+		{"middle slash anchors", []string{"docs/*.md"}, "docs/intro.md", false, true},
+		{"middle slash only at the base", []string{"docs/*.md"}, "site/docs/intro.md", false, false},
+		{"star stays within a directory", []string{"docs/*.md"}, "docs/guide/intro.md", false, false},
+		{"dot slash prefix", []string{"./dist"}, "dist", true, true},
+
+		{"leading ** matches at the top", []string{"**/logs"}, "logs", true, true},
+		{"leading ** matches at any depth", []string{"**/logs"}, "a/b/logs", true, true},
+		{"middle ** matches no directories", []string{"a/**/b"}, "a/b", true, true},
+		{"middle ** matches several directories", []string{"a/**/b"}, "a/x/y/b", true, true},
+		{"middle ** stays anchored", []string{"a/**/b"}, "c/a/x/b", true, false},
+		{"trailing ** matches inside", []string{"out/**"}, "out/x/y.js", false, true},
+		{"trailing ** does not match the directory", []string{"out/**"}, "out", true, false},
+
+		{"negation re-includes", []string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"negation leaves others ignored", []string{"*.log", "!keep.log"}, "other.log", false, true},
+		{"last matching rule wins", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"negation cannot re-include below an ignored directory", []string{"dist/", "!dist/keep.js"}, "dist/keep.js", false, true},
+		{"negation works when only the contents are ignored", []string{"dist/*", "!dist/keep.js"}, "dist/keep.js", false, false},
+
+		{"trailing slash matches directories", []string{"tmp/"}, "tmp", true, true},
+		{"trailing slash skips files", []string{"tmp/"}, "tmp", false, false},
+		{"question mark", []string{"file?.txt"}, "file1.txt", false, true},
+		{"question mark needs a character", []string{"file?.txt"}, "file.txt", false, false},
+		{"character class", []string{"v[0-9].js"}, "v2.js", false, true},
+		{"negated character class", []string{"[!a]bc"}, "abc", false, false},
+		{"escaped exclamation mark", []string{`\!important`}, "!important", false, true},
+		{"escaped hash", []string{`\#notes`}, "#notes", false, true},
+		{"comments and blank lines match nothing", []string{"# *.js", "", "   "}, "app.js", false, false},
+		{"trailing spaces are trimmed", []string{"*.tmp   "}, "a.tmp", false, true},
+	}
+
+	for _, tt := range tests {
+		var rules []types.IgnoreRule
+		for _, pattern := range tt.patterns {
+			rules = append(rules, types.IgnoreRule{Pattern: pattern, Base: root})
+		}
+		m := NewIgnoreMatcher(rules)
+		path := filepath.Join(root, filepath.FromSlash(tt.path))
+		if got := m.Match(path, tt.isDir); got != tt.want {
+			t.Errorf("%s: %q matching %q = %v, want %v", tt.name, tt.patterns, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreMatcherNestedBase(t *testing.T) {
+	root, err := filepath.Abs("project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "packages", "api")
+
+	m := NewIgnoreMatcher([]types.IgnoreRule{
+		{Pattern: "*.gen.ts", Base: sub},
+		{Pattern: "/dist", Base: sub},
+	})
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"packages/api/schema.gen.ts", true},
+		{"packages/api/src/schema.gen.ts", true},
+		{"schema.gen.ts", false},
+		{"packages/web/schema.gen.ts", false},
+		{"packages/api/dist", true},
+		{"dist", false},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(root, filepath.FromSlash(tt.path))
+		if got := m.Match(path, false); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"quickdev/internal/types"
+)
+
+// Configuration layers, lowest precedence first
+const (
+	LayerDefault = "default"
+	LayerFile    = "file"
+	LayerEnv     = "env"
+	LayerCLI     = "cli"
+)
+
+// EnvPrefix starts the environment variables that set config keys, e.g.
+// QUICKDEV_DEBOUNCE_MS for debounceMs
+const EnvPrefix = "QUICKDEV_"
+
+// Setting is a raw value for a config key from the defaults or the
+// command line
+type Setting struct {
+	Key    string // JSON key, e.g. "debounceMs"
+	Value  string // Value as typed; lists are comma separated
+	Source string // Where it came from, e.g. "-debounce"
+}
+
+// layer is one source of settings, held as JSON values by key
+type layer struct {
+	name    string
+	values  map[string]json.RawMessage
+	sources map[string]string
+}
+
+// newLayer creates an empty layer
+func newLayer(name string) *layer {
+	return &layer{
+		name:    name,
+		values:  make(map[string]json.RawMessage),
+		sources: make(map[string]string),
+	}
+}
+
+// settingsLayer converts defaults or command line settings into a layer
+func settingsLayer(name string, settings []Setting) (*layer, error) {
+	fields := configFields()
+	l := newLayer(name)
+	for _, setting := range settings {
+		fieldType, ok := fields[setting.Key]
+		if !ok {
+			return nil, fmt.Errorf("unknown config key %q for %s", setting.Key, setting.Source)
+		}
+		value, err := rawValue(fieldType, setting.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", setting.Source, setting.Value, err)
+		}
+		l.values[setting.Key] = value
+		l.sources[setting.Key] = setting.Source
+	}
+	return l, nil
+}
+
+// envLayer reads QUICKDEV_* variables from environ
+func envLayer(environ []string) (*layer, error) {
+	env := make(map[string]string, len(environ))
+	for _, entry := range environ {
+		if i := strings.IndexByte(entry, '='); i > 0 && strings.HasPrefix(entry, EnvPrefix) {
+			env[entry[:i]] = entry[i+1:]
+		}
+	}
+
+	l := newLayer(LayerEnv)
+	for key, fieldType := range configFields() {
+		name := EnvName(key)
+		text, ok := env[name]
+		if !ok {
+			continue
+		}
+		value, err := rawValue(fieldType, text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", name, text, err)
+		}
+		l.values[key] = value
+		l.sources[key] = name
+	}
+	return l, nil
+}
+
+// additiveKeys are lists that each layer adds to instead of replacing, so
+// an ignore list in a config file keeps node_modules and .git ignored.
+// Their entries are ignore patterns, so "!dist" takes a default back out.
+var additiveKeys = map[string]bool{
+	"ignore": true,
+}
+
+// resolve applies the layers in order, later layers overriding earlier
+// ones key by key, and records where each setting came from
+func resolve(layers ...*layer) (*types.FileWatcherConfig, error) {
+	merged := make(map[string]json.RawMessage)
+	origins := make(map[string]types.ConfigOrigin)
+	for _, l := range layers {
+		for key, value := range l.values {
+			if previous, ok := merged[key]; ok && additiveKeys[key] {
+				value = appendLists(previous, value)
+			}
+			merged[key] = value
+			origins[key] = types.ConfigOrigin{Layer: l.name, Source: l.sources[key]}
+		}
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var config types.FileWatcherConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	config.Origins = origins
+	return &config, nil
+}
+
+// appendLists joins two JSON lists. Anything else is returned as the
+// later value, so decoding reports it.
+func appendLists(first, second json.RawMessage) json.RawMessage {
+	var a, b []json.RawMessage
+	if json.Unmarshal(first, &a) != nil || json.Unmarshal(second, &b) != nil {
+		return second
+	}
+	joined, err := json.Marshal(append(a, b...))
+	if err != nil {
+		return second
+	}
+	return joined
+}
+
+// rawValue converts a command line or environment value to JSON for a
+// field of the given type. Lists are comma separated; maps and structs
+// are given as JSON.
+func rawValue(fieldType reflect.Type, text string) (json.RawMessage, error) {
+	var value interface{}
+	switch fieldType.Kind() {
+	case reflect.String:
+		value = text
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false")
+		}
+		value = b
+	case reflect.Int:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("expected a whole number")
+		}
+		value = n
+	case reflect.Slice:
+		if fieldType.Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(text), "[") {
+			list := []string{}
+			for _, item := range strings.Split(text, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			value = list
+			break
+		}
+		fallthrough
+	default:
+		if !json.Valid([]byte(text)) {
+			return nil, fmt.Errorf("expected JSON")
+		}
+		return json.RawMessage(text), nil
+	}
+	return json.Marshal(value)
+}
+
+// configFields maps the JSON keys of FileWatcherConfig to field types
+func configFields() map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	configType := reflect.TypeOf(types.FileWatcherConfig{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		if key := jsonKey(field); key != "" {
+			fields[key] = field.Type
+		}
+	}
+	return fields
+}
+
+// jsonKey returns the JSON name of a field, or "" if it is not encoded
+func jsonKey(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" || name == "" {
+		return ""
+	}
+	return name
+}
+
+// EnvName returns the environment variable for a config key, e.g.
+// QUICKDEV_DEBOUNCE_MS for debounceMs
+func EnvName(key string) string {
+	var name strings.Builder
+	name.WriteString(EnvPrefix)
+	for i, r := range key {
+		if unicode.IsUpper(r) && i > 0 {
+			name.WriteByte('_')
+		}
+		name.WriteRune(unicode.ToUpper(r))
+	}
+	return name.String()
+}
+
+// ExplainedSetting is an effective setting and where it came from
+type ExplainedSetting struct {
+	Key    string
+	Value  string
+	Origin types.ConfigOrigin
+}
+
+// Explain lists every setting of config in declaration order, with its
+// value as JSON and the layer that set it
+func Explain(config *types.FileWatcherConfig) []ExplainedSetting {
+	var settings []ExplainedSetting
+	configValue := reflect.ValueOf(*config)
+	configType := configValue.Type()
+	for i := 0; i < configType.NumField(); i++ {
+		key := jsonKey(configType.Field(i))
+		if key == "" {
+			continue
+		}
+
+		value, err := json.Marshal(configValue.Field(i).Interface())
+		if err != nil {
+			value = []byte(fmt.Sprintf("%v", configValue.Field(i).Interface()))
+		}
+
+		origin, ok := config.Origins[key]
+		if !ok {
+			origin = types.ConfigOrigin{Layer: LayerDefault}
+		}
+		settings = append(settings, ExplainedSetting{Key: key, Value: string(value), Origin: origin})
+	}
+	return settings
+}
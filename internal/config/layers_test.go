@@ -0,0 +1,191 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+func TestRawValue(t *testing.T) {
+	tests := []struct {
+		value   interface{} // zero value of the field type
+		text    string
+		want    string
+		wantErr string
+	}{
+		{"", "src/app.ts", `"src/app.ts"`, ""},
+		{false, "true", "true", ""},
+		{false, "yes", "", "expected true or false"},
+		{0, "300", "300", ""},
+		{0, "3s", "", "expected a whole number"},
+		{[]string{}, "dist, coverage,,", `["dist","coverage"]`, ""},
+		{[]string{}, "", `[]`, ""},
+		{[]string{}, `["a,b"]`, `["a,b"]`, ""},
+		{map[string]string{}, `{".py": "python3"}`, `{".py": "python3"}`, ""},
+		{map[string]string{}, `.py=python3`, "", "expected JSON"},
+		{&types.HealthProbe{}, `{"type": "tcp"}`, `{"type": "tcp"}`, ""},
+	}
+
+	for _, tt := range tests {
+		fieldType := reflect.TypeOf(tt.value)
+		got, err := rawValue(fieldType, tt.text)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%s %q: error = %v, want %q", fieldType, tt.text, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: unexpected error: %v", fieldType, tt.text, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s %q = %s, want %s", fieldType, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"script", "QUICKDEV_SCRIPT"},
+		{"debounceMs", "QUICKDEV_DEBOUNCE_MS"},
+		{"gracefulShutdownTimeout", "QUICKDEV_GRACEFUL_SHUTDOWN_TIMEOUT"},
+	}
+
+	for _, tt := range tests {
+		if got := EnvName(tt.key); got != tt.want {
+			t.Errorf("EnvName(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestEnvLayer(t *testing.T) {
+	l, err := envLayer([]string{
+		"QUICKDEV_DEBOUNCE_MS=300",
+		"QUICKDEV_EXTENSIONS=.ts,.js",
+		"QUICKDEV_UNKNOWN_KEY=1",
+		"DEBOUNCE_MS=1",
+		"PATH=/usr/bin",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"debounceMs": "300", "extensions": `[".ts",".js"]`}
+	got := make(map[string]string)
+	for key, value := range l.values {
+		got[key] = string(value)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("values = %v, want %v", got, want)
+	}
+	if source := l.sources["debounceMs"]; source != "QUICKDEV_DEBOUNCE_MS" {
+		t.Errorf("debounceMs from %q, want QUICKDEV_DEBOUNCE_MS", source)
+	}
+
+	_, err = envLayer([]string{"QUICKDEV_DEBOUNCE_MS=fast"})
+	if want := `invalid QUICKDEV_DEBOUNCE_MS "fast": expected a whole number`; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	// layerOf builds a layer from JSON values, with the layer name as source
+	layerOf := func(name string, values map[string]string) *layer {
+		l := newLayer(name)
+		for key, value := range values {
+			l.values[key] = []byte(value)
+			l.sources[key] = name
+		}
+		return l
+	}
+
+	cfg, err := resolve(
+		layerOf(LayerDefault, map[string]string{"debounceMs": "100", "restartDelay": "10", "maxRestarts": "5", "ignore": `["node_modules", ".git"]`}),
+		layerOf(LayerFile, map[string]string{"debounceMs": "200", "restartDelay": "20", "ignore": `["dist"]`}),
+		layerOf(LayerEnv, map[string]string{"debounceMs": "300"}),
+		layerOf(LayerCLI, map[string]string{"debounceMs": "400", "ignore": `["!dist"]`}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.DebounceMs != 400 || cfg.RestartDelay != 20 || cfg.MaxRestarts != 5 {
+		t.Errorf("debounceMs, restartDelay, maxRestarts = %d, %d, %d, want 400, 20, 5", cfg.DebounceMs, cfg.RestartDelay, cfg.MaxRestarts)
+	}
+	if want := []string{"node_modules", ".git", "dist", "!dist"}; !reflect.DeepEqual(cfg.IgnorePaths, want) {
+		t.Errorf("ignore = %q, want %q", cfg.IgnorePaths, want)
+	}
+
+	wantOrigins := map[string]string{
+		"debounceMs":   LayerCLI,
+		"restartDelay": LayerFile,
+		"maxRestarts":  LayerDefault,
+		"ignore":       LayerCLI,
+	}
+	for key, want := range wantOrigins {
+		if got := cfg.Origins[key].Layer; got != want {
+			t.Errorf("%s from the %q layer, want %q", key, got, want)
+		}
+	}
+	if _, ok := cfg.Origins["script"]; ok {
+		t.Error("unset script has an origin")
+	}
+}
+
+func TestResolveTypeError(t *testing.T) {
+	l := newLayer(LayerEnv)
+	l.values["ignore"] = []byte(`"dist"`)
+	defaults := newLayer(LayerDefault)
+	defaults.values["ignore"] = []byte(`["node_modules"]`)
+
+	// A value that is not a list replaces the list, so decoding reports it
+	if _, err := resolve(defaults, l); err == nil {
+		t.Error("expected an error for a string ignore value")
+	}
+}
+
+func TestExplain(t *testing.T) {
+	cfg := &types.FileWatcherConfig{
+		Script:     "/p/app.js",
+		DebounceMs: 300,
+		Origins: map[string]types.ConfigOrigin{
+			"script":     {Layer: LayerFile, Source: "quickdev.config.json"},
+			"debounceMs": {Layer: LayerEnv, Source: "QUICKDEV_DEBOUNCE_MS"},
+		},
+	}
+
+	settings := make(map[string]ExplainedSetting)
+	var keys []string
+	for _, setting := range Explain(cfg) {
+		settings[setting.Key] = setting
+		keys = append(keys, setting.Key)
+	}
+
+	if len(keys) != len(configFields()) {
+		t.Errorf("explained %d settings, want %d", len(keys), len(configFields()))
+	}
+	if want := []string{"enabled", "watch", "ignore", "script"}; !reflect.DeepEqual(keys[:4], want) {
+		t.Errorf("first settings %q, want %q in declaration order", keys[:4], want)
+	}
+
+	tests := []struct {
+		key    string
+		value  string
+		origin types.ConfigOrigin
+	}{
+		{"script", `"/p/app.js"`, types.ConfigOrigin{Layer: LayerFile, Source: "quickdev.config.json"}},
+		{"debounceMs", "300", types.ConfigOrigin{Layer: LayerEnv, Source: "QUICKDEV_DEBOUNCE_MS"}},
+		{"maxRestarts", "0", types.ConfigOrigin{Layer: LayerDefault}},
+	}
+	for _, tt := range tests {
+		got := settings[tt.key]
+		if got.Value != tt.value || got.Origin != tt.origin {
+			t.Errorf("%s = %s from %+v, want %s from %+v", tt.key, got.Value, got.Origin, tt.value, tt.origin)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// position is a line and column in a config file, both starting at 1
+type position struct {
+	line   int
+	column int
+}
+
+// keyPath joins a key onto the path of its parent object, e.g. "rules[0]"
+// and "action" become "rules[0].action"
+func keyPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// indexPath appends a list index to a path
+func indexPath(parent string, index int) string {
+	return parent + "[" + strconv.Itoa(index) + "]"
+}
+
+// locateKey returns where the key at path, or the list entry for paths
+// ending in an index, is written in a config source
+func locateKey(configFile, path string) (position, bool) {
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return position{}, false
+	}
+
+	var positions map[string]position
+	switch name := filepath.Base(configFile); {
+	case name == PackageFileName:
+		positions = jsonKeyPositions(data)
+		path = keyPath(PackageConfigKey, path)
+	case strings.HasSuffix(name, ".yaml"), strings.HasSuffix(name, ".yml"):
+		positions = yamlKeyPositions(data)
+	case strings.HasSuffix(name, ".toml"):
+		positions = tomlKeyPositions(data)
+	default:
+		positions = jsonKeyPositions(data)
+	}
+
+	pos, ok := positions[path]
+	return pos, ok
+}
+
+// jsonKeyPositions maps the path of every key in a JSON document to the
+// position of its opening quote, and of every list entry to its start
+func jsonKeyPositions(data []byte) map[string]position {
+	type frame struct {
+		object bool
+		path   string
+		key    string // last key read in an object
+		index  int    // next index in an array
+		isKey  bool   // the next string in an object is a key
+	}
+
+	positions := make(map[string]position)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var stack []*frame
+
+	// childPath returns the path of the value about to be read
+	childPath := func() string {
+		top := stack[len(stack)-1]
+		if top.object {
+			return keyPath(top.path, top.key)
+		}
+		return indexPath(top.path, top.index)
+	}
+	// valueDone moves the innermost container past a complete value
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			top.isKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			return positions
+		}
+
+		if len(stack) > 0 {
+			if top := stack[len(stack)-1]; top.object && top.isKey {
+				if key, ok := token.(string); ok {
+					start := int(offset) + bytes.IndexByte(data[offset:], '"')
+					positions[keyPath(top.path, key)] = offsetPosition(data, start)
+					top.key = key
+					top.isKey = false
+					continue
+				}
+			}
+		}
+
+		if len(stack) > 0 && !stack[len(stack)-1].object && token != json.Delim(']') {
+			start := int(offset) + len(data[offset:]) - len(bytes.TrimLeft(data[offset:], " \t\r\n,"))
+			positions[childPath()] = offsetPosition(data, start)
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			path := ""
+			if len(stack) > 0 {
+				path = childPath()
+			}
+			stack = append(stack, &frame{object: token == json.Delim('{'), path: path, isKey: true})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			valueDone()
+		default:
+			valueDone()
+		}
+	}
+}
+
+// offsetPosition converts a byte offset into a position
+func offsetPosition(data []byte, offset int) position {
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(before, '\n')
+	return position{line: line, column: column}
+}
+
+// yamlKeyPositions maps the path of every key and list entry in a YAML
+// document to its position
+func yamlKeyPositions(data []byte) map[string]position {
+	positions := make(map[string]position)
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return positions
+	}
+
+	var walk func(node *yaml.Node, path string)
+	walk = func(node *yaml.Node, path string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i]
+				childPath := keyPath(path, key.Value)
+				positions[childPath] = position{line: key.Line, column: key.Column}
+				walk(node.Content[i+1], childPath)
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				positions[indexPath(path, i)] = position{line: child.Line, column: child.Column}
+				walk(child, indexPath(path, i))
+			}
+		}
+	}
+	walk(&document, "")
+	return positions
+}
+
+// tomlKeyPositions maps the path of every key in a TOML document to its
+// position. The TOML decoder does not report positions, so this reads
+// table headers and "key = value" lines; keys inside multi-line values and
+// inline tables are not found.
+func tomlKeyPositions(data []byte) map[string]position {
+	positions := make(map[string]position)
+	arrays := make(map[string]int) // entries seen per array of tables
+	table := ""
+
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		column := len(line) - len(strings.TrimLeft(line, " \t")) + 1
+
+		switch {
+		case strings.HasPrefix(trimmed, "[["):
+			end := strings.Index(trimmed, "]]")
+			if end < 0 {
+				continue
+			}
+			name := tomlKey(trimmed[2:end])
+			table = indexPath(name, arrays[name])
+			arrays[name]++
+			positions[table] = position{line: i + 1, column: column}
+			if _, ok := positions[name]; !ok {
+				positions[name] = position{line: i + 1, column: column}
+			}
+		case strings.HasPrefix(trimmed, "["):
+			end := strings.Index(trimmed, "]")
+			if end < 0 {
+				continue
+			}
+			table = tomlKey(trimmed[1:end])
+			positions[table] = position{line: i + 1, column: column}
+		default:
+			eq := strings.Index(trimmed, "=")
+			if eq < 0 {
+				continue
+			}
+			positions[keyPath(table, tomlKey(trimmed[:eq]))] = position{line: i + 1, column: column}
+		}
+	}
+	return positions
+}
+
+// tomlKey normalizes a possibly dotted and quoted TOML key
+func tomlKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
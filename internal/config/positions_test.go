@@ -0,0 +1,108 @@
+package config
+
+import "testing"
+
+// checkPositions compares the positions found for some paths
+func checkPositions(t *testing.T, format string, positions map[string]position, want map[string]position) {
+	t.Helper()
+	for path, pos := range want {
+		got, ok := positions[path]
+		if !ok {
+			t.Errorf("%s: no position for %q", format, path)
+			continue
+		}
+		if got != pos {
+			t.Errorf("%s: position of %q = %d:%d, want %d:%d", format, path, got.line, got.column, pos.line, pos.column)
+		}
+	}
+}
+
+func TestJSONKeyPositions(t *testing.T) {
+	data := []byte(`{
+  "script": "a.js",
+  "rules": [
+    { "pattern": "*.md", "action": "notify-only" },
+    {
+      "pattern": "x"
+    }
+  ],
+  "healthProbe": {"type": "http"},
+  "runners": { ".ts": "bun" }
+}
+`)
+
+	checkPositions(t, "json", jsonKeyPositions(data), map[string]position{
+		"script":           {2, 3},
+		"rules":            {3, 3},
+		"rules[0]":         {4, 5},
+		"rules[0].pattern": {4, 7},
+		"rules[0].action":  {4, 26},
+		"rules[1]":         {5, 5},
+		"rules[1].pattern": {6, 7},
+		"healthProbe":      {9, 3},
+		"healthProbe.type": {9, 19},
+		"runners..ts":      {10, 16},
+	})
+}
+
+func TestJSONKeyPositionsInvalid(t *testing.T) {
+	// Positions read before the syntax error are still reported
+	positions := jsonKeyPositions([]byte(`{"script": "a.js", "watch": [`))
+	checkPositions(t, "json", positions, map[string]position{
+		"script": {1, 2},
+	})
+}
+
+func TestYAMLKeyPositions(t *testing.T) {
+	data := []byte(`script: a.js
+rules:
+  - pattern: "*.md"
+    action: notify-only
+  - pattern: x
+healthProbe:
+  type: http
+runners:
+  .ts: bun
+`)
+
+	checkPositions(t, "yaml", yamlKeyPositions(data), map[string]position{
+		"script":           {1, 1},
+		"rules":            {2, 1},
+		"rules[0]":         {3, 5},
+		"rules[0].pattern": {3, 5},
+		"rules[0].action":  {4, 5},
+		"rules[1].pattern": {5, 5},
+		"healthProbe.type": {7, 3},
+		"runners..ts":      {9, 3},
+	})
+}
+
+func TestTOMLKeyPositions(t *testing.T) {
+	data := []byte(`# quickdev
+script = "a.js"
+healthProbe.type = "http"
+
+[runners]
+".ts" = "bun"
+
+[[rules]]
+pattern = "*.md"
+  action = "notify-only"
+
+[[rules]]
+pattern = "x"
+`)
+
+	checkPositions(t, "toml", tomlKeyPositions(data), map[string]position{
+		"script":           {2, 1},
+		"healthProbe.type": {3, 1},
+		"runners":          {5, 1},
+		"runners..ts":      {6, 1},
+		"rules":            {8, 1},
+		"rules[0]":         {8, 1},
+		"rules[0].pattern": {9, 1},
+		"rules[0].action":  {10, 3},
+		"rules[1]":         {12, 1},
+		"rules[1].pattern": {13, 1},
+	})
+}
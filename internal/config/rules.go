@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+
+	"quickdev/internal/types"
+)
+
+// RuleSet matches changed paths against the configured watch rules
+type RuleSet struct {
+	rules []compiledRule
+}
+
+// compiledRule is a watch rule with its pattern compiled
+type compiledRule struct {
+	rule    types.WatchRule
+	matcher *IgnoreMatcher
+}
+
+// NewRuleSet compiles rules whose patterns are relative to base. Patterns
+// use the ignore file syntax, so "prisma/schema.prisma", "*.graphql" and
+// "generated/" all work.
+func NewRuleSet(rules []types.WatchRule, base string) *RuleSet {
+	rs := &RuleSet{}
+	for _, rule := range rules {
+		rs.rules = append(rs.rules, compiledRule{
+			rule:    rule,
+			matcher: NewIgnoreMatcher([]types.IgnoreRule{{Pattern: rule.Pattern, Base: base}}),
+		})
+	}
+	return rs
+}
+
+// Match returns the first rule matching path
+func (rs *RuleSet) Match(path string) (types.WatchRule, bool) {
+	for _, compiled := range rs.rules {
+		if compiled.matcher.Match(path, false) {
+			return compiled.rule, true
+		}
+	}
+	return types.WatchRule{}, false
+}
+
+// validateRules checks the rules section and fills in the default action
+func validateRules(rules []types.WatchRule) error {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Pattern == "" {
+			return &keyError{path: indexPath("rules", i), err: fmt.Errorf("invalid rules[%d]: pattern is required", i)}
+		}
+
+		switch rule.Action {
+		case "":
+			rule.Action = types.RuleActionRestart
+		case types.RuleActionRestart, types.RuleActionIgnore, types.RuleActionNotifyOnly:
+		case types.RuleActionRunCommand:
+			if rule.Command == "" {
+				return &keyError{path: indexPath("rules", i), err: fmt.Errorf("invalid rules[%d] %q: run-command needs a command", i, rule.Pattern)}
+			}
+		default:
+			return &keyError{path: indexPath("rules", i) + ".action", err: fmt.Errorf("invalid rules[%d] %q: unknown action %q (use restart, ignore, run-command or notify-only)", i, rule.Pattern, rule.Action)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+func TestRuleSetMatch(t *testing.T) {
+	root, err := filepath.Abs("project")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := NewRuleSet([]types.WatchRule{
+		{Pattern: "prisma/schema.prisma", Action: types.RuleActionRunCommand, Command: "npx prisma generate"},
+		{Pattern: "*.test.ts", Action: types.RuleActionIgnore},
+		{Pattern: "*.ts", Action: types.RuleActionRestart},
+		{Pattern: "docs/", Action: types.RuleActionNotifyOnly},
+	}, root)
+
+	tests := []struct {
+		path string
+		want string // action, empty when no rule matches
+	}{
+		{"prisma/schema.prisma", types.RuleActionRunCommand},
+		{"other/prisma/schema.prisma", ""},
+		{"src/app.test.ts", types.RuleActionIgnore},
+		{"src/app.ts", types.RuleActionRestart},
+		{"docs/intro.md", types.RuleActionNotifyOnly},
+		{"src/app.js", ""},
+	}
+
+	for _, tt := range tests {
+		rule, ok := rules.Match(filepath.Join(root, filepath.FromSlash(tt.path)))
+		if !ok {
+			rule.Action = ""
+		}
+		if rule.Action != tt.want {
+			t.Errorf("%s: action = %q, want %q", tt.path, rule.Action, tt.want)
+		}
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		rule       types.WatchRule
+		wantAction string
+		wantErr    string
+		wantPath   string
+	}{
+		{types.WatchRule{Pattern: "*.ts"}, types.RuleActionRestart, "", ""},
+		{types.WatchRule{Pattern: "*.md", Action: types.RuleActionNotifyOnly}, types.RuleActionNotifyOnly, "", ""},
+		{types.WatchRule{Pattern: "*.prisma", Action: types.RuleActionRunCommand, Command: "npx prisma generate"}, types.RuleActionRunCommand, "", ""},
+		{types.WatchRule{Action: types.RuleActionIgnore}, "",
+			"invalid rules[1]: pattern is required", "rules[1]"},
+		{types.WatchRule{Pattern: "*.prisma", Action: types.RuleActionRunCommand}, "",
+			`invalid rules[1] "*.prisma": run-command needs a command`, "rules[1]"},
+		{types.WatchRule{Pattern: "*.ts", Action: "reload"}, "",
+			`invalid rules[1] "*.ts": unknown action "reload" (use restart, ignore, run-command or notify-only)`, "rules[1].action"},
+	}
+
+	for _, tt := range tests {
+		// A valid rule first, so the index in the error is checked
+		rules := []types.WatchRule{{Pattern: "*.js"}, tt.rule}
+		err := validateRules(rules)
+		if tt.wantErr != "" {
+			keyErr, ok := err.(*keyError)
+			if !ok || err.Error() != tt.wantErr || keyErr.path != tt.wantPath {
+				t.Errorf("%+v: error = %v, want %q at %s", tt.rule, err, tt.wantErr, tt.wantPath)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: unexpected error: %v", tt.rule, err)
+			continue
+		}
+		if rules[1].Action != tt.wantAction {
+			t.Errorf("%+v: action = %q, want %q", tt.rule, rules[1].Action, tt.wantAction)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"reflect"
+
+	"quickdev/internal/types"
+)
+
+// SchemaURI is the JSON Schema dialect Schema is written in
+const SchemaURI = "http://json-schema.org/draft-07/schema#"
+
+// Schema returns a JSON Schema for config files, generated from
+// types.FileWatcherConfig and the same constraints LoadConfig checks, so
+// editors can complete and check keys. Point "$schema" at it to use it.
+func Schema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(types.FileWatcherConfig{}), "")
+	schema["$schema"] = SchemaURI
+	schema["title"] = "quickdev configuration"
+	schema["properties"].(map[string]interface{})[SchemaKey] = map[string]interface{}{
+		"type":        "string",
+		"description": "URI of this schema, for editors",
+	}
+	return schema
+}
+
+// typeSchema describes the values of type t found at key, a key path
+// without list indexes
+func typeSchema(t reflect.Type, key string) map[string]interface{} {
+	// Unset pointers are written as null
+	if t.Kind() == reflect.Ptr {
+		return nullable(typeSchema(t.Elem(), key))
+	}
+
+	c := constraints[key]
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int:
+		schema := map[string]interface{}{"type": "integer", "minimum": c.minimum}
+		if c.maximum > 0 {
+			schema["maximum"] = c.maximum
+		}
+		return schema
+	case reflect.String:
+		if c.enum == nil {
+			return map[string]interface{}{"type": "string"}
+		}
+		// An empty string leaves the setting at its default
+		enum := append([]string{""}, c.enum...)
+		if c.templates {
+			return map[string]interface{}{
+				"anyOf": []interface{}{
+					map[string]interface{}{"enum": enum},
+					map[string]interface{}{"type": "string", "pattern": `\{script\}|\s`},
+				},
+			}
+		}
+		return map[string]interface{}{"enum": enum}
+	case reflect.Slice:
+		return nullable(map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), key)})
+	case reflect.Map:
+		return nullable(map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), key)})
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			name := jsonKey(t.Field(i))
+			if name == "" {
+				continue
+			}
+			properties[name] = typeSchema(t.Field(i).Type, keyPath(key, name))
+		}
+
+		schema := map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if required := requiredKeys[key]; required != nil {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// nullable lets a schema with a single type also accept null, as Go
+// writes unset lists, maps and pointers
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if t, ok := schema["type"].(string); ok {
+		schema["type"] = []string{t, "null"}
+	}
+	return schema
+}
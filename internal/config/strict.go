@@ -0,0 +1,255 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"quickdev/internal/types"
+)
+
+// SchemaKey is the key editors use to find the schema of a config file;
+// it is accepted at the top level and otherwise ignored
+const SchemaKey = "$schema"
+
+// keyError is an error about the setting at path, e.g. "rules[0].action"
+type keyError struct {
+	path string
+	err  error
+}
+
+func (e *keyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *keyError) Unwrap() error {
+	return e.err
+}
+
+// unknownKey is a key that no config field decodes
+type unknownKey struct {
+	path       string
+	suggestion string
+}
+
+// checkKeys rejects keys of a config file that would otherwise be silently
+// dropped, suggesting the closest known key and pointing at each one
+func checkKeys(configFile string, data []byte) error {
+	var values interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("error parsing %s: %v", configSourceName(configFile), err)
+	}
+
+	var unknown []unknownKey
+	findUnknownKeys(values, reflect.TypeOf(types.FileWatcherConfig{}), "", &unknown)
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	type located struct {
+		pos     position
+		message string
+	}
+	var messages []located
+	for _, u := range unknown {
+		message := fmt.Sprintf("unknown key %q", u.path)
+		if u.suggestion != "" {
+			message += fmt.Sprintf(", did you mean %q?", u.suggestion)
+		}
+		pos, ok := locateKey(configFile, u.path)
+		if ok {
+			message = fmt.Sprintf("%s:%d:%d: %s", configFile, pos.line, pos.column, message)
+		} else {
+			message = fmt.Sprintf("%s: %s", configSourceName(configFile), message)
+		}
+		messages = append(messages, located{pos: pos, message: message})
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		if messages[i].pos.line != messages[j].pos.line {
+			return messages[i].pos.line < messages[j].pos.line
+		}
+		return messages[i].pos.column < messages[j].pos.column
+	})
+	lines := make([]string, len(messages))
+	for i, m := range messages {
+		lines[i] = m.message
+	}
+	return errors.New(strings.Join(lines, "\n  "))
+}
+
+// findUnknownKeys walks a decoded JSON value alongside the type it decodes
+// into and collects the keys that type has no field for
+func findUnknownKeys(value interface{}, t reflect.Type, path string, unknown *[]unknownKey) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := structFields(t)
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if path == "" && key == SchemaKey {
+				continue
+			}
+			field, ok := fields[key]
+			if !ok {
+				*unknown = append(*unknown, unknownKey{
+					path:       keyPath(path, key),
+					suggestion: closestKey(key, fields),
+				})
+				continue
+			}
+			findUnknownKeys(object[key], field, keyPath(path, key), unknown)
+		}
+	case reflect.Slice:
+		list, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range list {
+			findUnknownKeys(item, t.Elem(), indexPath(path, i), unknown)
+		}
+	}
+}
+
+// structFields maps the JSON keys of a struct to field types
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		if key := jsonKey(t.Field(i)); key != "" {
+			fields[key] = t.Field(i).Type
+		}
+	}
+	return fields
+}
+
+// closestKey returns the known key a typo most likely meant, or "" if none
+// is close enough
+func closestKey(key string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", -1
+	for candidate := range fields {
+		distance := editDistance(strings.ToLower(key), strings.ToLower(candidate))
+		if bestDistance < 0 || distance < bestDistance || (distance == bestDistance && candidate < best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	if bestDistance < 0 || bestDistance > 2 && bestDistance > len(key)/3 {
+		return ""
+	}
+	return best
+}
+
+// closestValue returns the allowed value a typo most likely meant
+func closestValue(value string, allowed []string) string {
+	fields := make(map[string]reflect.Type, len(allowed))
+	for _, a := range allowed {
+		fields[a] = nil
+	}
+	return closestKey(value, fields)
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// typeError rewrites a JSON type error from decoding a config file so it
+// names the key and where it is written
+func typeError(configFile string, err error) error {
+	var unmarshalErr *json.UnmarshalTypeError
+	if !errors.As(err, &unmarshalErr) || unmarshalErr.Field == "" {
+		return fmt.Errorf("error parsing %s: %v", configSourceName(configFile), err)
+	}
+
+	// The decoder writes list indexes as path segments, e.g. "rules.1.action"
+	path := ""
+	for _, segment := range strings.Split(unmarshalErr.Field, ".") {
+		if index, err := strconv.Atoi(segment); err == nil {
+			path = indexPath(path, index)
+		} else {
+			path = keyPath(path, segment)
+		}
+	}
+
+	message := fmt.Sprintf("invalid value for %s: expected %s, got %s", path, jsonTypeName(unmarshalErr.Type), unmarshalErr.Value)
+	if pos, ok := locateKey(configFile, path); ok {
+		return fmt.Errorf("%s:%d:%d: %s", configFile, pos.line, pos.column, message)
+	}
+	return fmt.Errorf("%s: %s", configSourceName(configFile), message)
+}
+
+// jsonTypeName names a Go type the way config files spell values
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64, reflect.Uint64:
+		return "a whole number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice:
+		return "a list"
+	default:
+		return "an object"
+	}
+}
+
+// locateError points a validation error at the setting that caused it:
+// its line and column for the config file, or the variable or flag that
+// set it
+func locateError(cfg *types.FileWatcherConfig, err error) error {
+	var ke *keyError
+	if !errors.As(err, &ke) {
+		return err
+	}
+
+	top := ke.path
+	if i := strings.IndexAny(top, ".["); i >= 0 {
+		top = top[:i]
+	}
+
+	origin := cfg.Origins[top]
+	switch origin.Layer {
+	case LayerFile:
+		if pos, ok := locateKey(cfg.ConfigFile, ke.path); ok {
+			return fmt.Errorf("%s:%d:%d: %v", cfg.ConfigFile, pos.line, pos.column, err)
+		}
+		return fmt.Errorf("%s: %v", origin.Source, err)
+	case LayerEnv, LayerCLI:
+		return fmt.Errorf("%v (set by %s)", err, origin.Source)
+	}
+	return err
+}
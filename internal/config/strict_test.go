@@ -0,0 +1,132 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"script", "script", 0},
+		{"scirpt", "script", 2},
+		{"enableHasing", "enableHashing", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosestValue(t *testing.T) {
+	allowed := []string{"trailing", "leading", "both"}
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"trailng", "trailing"},
+		{"Leading", "leading"},
+		{"bth", "both"},
+		{"sometimes", ""},
+	}
+
+	for _, tt := range tests {
+		if got := closestValue(tt.value, allowed); got != tt.want {
+			t.Errorf("closestValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFindUnknownKeys(t *testing.T) {
+	data := `{
+		"$schema": "./quickdev.schema.json",
+		"script": "a.js",
+		"enableHasing": true,
+		"rules": [{"pattern": "*.md"}, {"pattern": "x", "comand": "y"}],
+		"healthProbe": {"typ": "http"},
+		"hooks": {"beforeStrat": [], "$schema": "x"},
+		"runners": {".ts": "bun"},
+		"somethingElse": 1
+	}`
+	var values interface{}
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		t.Fatal(err)
+	}
+
+	var unknown []unknownKey
+	findUnknownKeys(values, reflect.TypeOf(types.FileWatcherConfig{}), "", &unknown)
+
+	want := []unknownKey{
+		{path: "enableHasing", suggestion: "enableHashing"},
+		{path: "healthProbe.typ", suggestion: "type"},
+		{path: "hooks.$schema", suggestion: ""},
+		{path: "hooks.beforeStrat", suggestion: "beforeStart"},
+		{path: "rules[1].comand", suggestion: "command"},
+		{path: "somethingElse", suggestion: ""},
+	}
+	if !reflect.DeepEqual(unknown, want) {
+		t.Errorf("unknown keys = %+v, want %+v", unknown, want)
+	}
+}
+
+func TestCheckKeysPositions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quickdev.config.json")
+	data := []byte("{\n  \"script\": \"a.js\",\n  \"watchh\": [\".\"],\n  \"enableHasing\": true\n}\n")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := checkKeys(file, data)
+	if err == nil {
+		t.Fatal("checkKeys: expected an error")
+	}
+	want := file + `:3:3: unknown key "watchh", did you mean "watch"?` + "\n  " +
+		file + `:4:3: unknown key "enableHasing", did you mean "enableHashing"?`
+	if err.Error() != want {
+		t.Errorf("checkKeys error:\n%s\nwant:\n%s", err, want)
+	}
+
+	if err := checkKeys(file, []byte(`{"script": "a.js", "$schema": "x"}`)); err != nil {
+		t.Errorf("checkKeys with known keys: unexpected error: %v", err)
+	}
+}
+
+func TestTypeError(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`{"debounceMs": "fast"}`, "invalid value for debounceMs: expected a whole number, got string"},
+		{`{"watch": "src"}`, "invalid value for watch: expected a list, got string"},
+		{`{"rules": [{"pattern": "a"}, {"pattern": 5}]}`, "invalid value for rules[1].pattern: expected a string, got number"},
+		{`{"healthProbe": {"type": "http", "timeout": true}}`, "invalid value for healthProbe.timeout: expected a whole number, got bool"},
+	}
+
+	file := filepath.Join(t.TempDir(), "quickdev.config.json")
+	for _, tt := range tests {
+		var cfg types.FileWatcherConfig
+		err := json.Unmarshal([]byte(tt.data), &cfg)
+		if err == nil {
+			t.Errorf("decoding %s: expected an error", tt.data)
+			continue
+		}
+		got := typeError(file, err).Error()
+		if !strings.HasSuffix(got, tt.want) {
+			t.Errorf("typeError for %s = %q, want it to end in %q", tt.data, got, tt.want)
+		}
+	}
+}
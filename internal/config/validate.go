@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"quickdev/internal/types"
+)
+
+// constraint limits the values of a setting
+type constraint struct {
+	minimum   int      // Smallest allowed number
+	maximum   int      // Largest allowed number, no limit if 0
+	enum      []string // Allowed strings; "" always means unset
+	templates bool     // Command templates are allowed besides enum
+}
+
+// constraints are the limits of settings by key path without list
+// indexes. Numbers not listed here must not be negative.
+var constraints = map[string]constraint{
+	"pollingInterval":          {minimum: 1},
+	"healthCheckInterval":      {minimum: 1},
+	"debounceEdge":             {enum: []string{"trailing", "leading", "both"}},
+	"memoryLimitAction":        {enum: []string{"restart", "kill"}},
+	"typescriptRunner":         {enum: types.RunnerNames, templates: true},
+	"runners":                  {enum: types.RunnerNames, templates: true},
+	"rules.action":             {enum: []string{types.RuleActionRestart, types.RuleActionIgnore, types.RuleActionRunCommand, types.RuleActionNotifyOnly}},
+	"healthProbe.type":         {enum: []string{types.ProbeHTTP, types.ProbeTCP, types.ProbeCommand}},
+	"healthProbe.expectStatus": {maximum: 599},
+}
+
+// requiredKeys are the keys objects in the config must have, by key path
+var requiredKeys = map[string][]string{
+	"rules":       {"pattern"},
+	"healthProbe": {"type"},
+}
+
+// validateConfig checks settings that cannot be checked while decoding
+// and fills in defaults that depend on other settings
+func validateConfig(cfg *types.FileWatcherConfig) error {
+	if err := validateRules(cfg.Rules); err != nil {
+		return err
+	}
+
+	if err := checkConstraints(reflect.ValueOf(*cfg), "", ""); err != nil {
+		return err
+	}
+
+	for ext, runner := range cfg.Runners {
+		if strings.TrimSpace(runner) == "" {
+			return &keyError{path: keyPath("runners", ext), err: fmt.Errorf("invalid runners[%q]: runner is empty", ext)}
+		}
+	}
+
+	if cfg.HealthProbe != nil {
+		if err := validateHealthProbe(cfg.HealthProbe); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkConstraints checks every number and enum in v against constraints.
+// path locates v for messages and key is path without list indexes.
+func checkConstraints(v reflect.Value, path, key string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return checkConstraints(v.Elem(), path, key)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name := jsonKey(v.Type().Field(i))
+			if name == "" {
+				continue
+			}
+			if err := checkConstraints(v.Field(i), keyPath(path, name), keyPath(key, name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := checkConstraints(v.Index(i), indexPath(path, i), key); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Values are checked in key order so errors are stable
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			value := v.MapIndex(reflect.ValueOf(k))
+			if value.Kind() == reflect.String {
+				name := fmt.Sprintf("%s[%q]", path, k)
+				if err := checkEnum(value.String(), keyPath(path, k), name, key); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := checkConstraints(value, keyPath(path, k), key); err != nil {
+				return err
+			}
+		}
+	case reflect.Int:
+		c := constraints[key]
+		n := int(v.Int())
+		if n < c.minimum {
+			return &keyError{path: path, err: fmt.Errorf("invalid %s %d: must be at least %d", path, n, c.minimum)}
+		}
+		if c.maximum > 0 && n > c.maximum {
+			return &keyError{path: path, err: fmt.Errorf("invalid %s %d: must be at most %d", path, n, c.maximum)}
+		}
+	case reflect.String:
+		return checkEnum(v.String(), path, path, key)
+	}
+	return nil
+}
+
+// checkEnum checks a string against the enum of key. path locates it and
+// name is how messages refer to it.
+func checkEnum(value, path, name, key string) error {
+	c, ok := constraints[key]
+	if !ok || c.enum == nil || value == "" || contains(c.enum, value) {
+		return nil
+	}
+	if c.templates {
+		return checkCommand(value, path, name, c.enum)
+	}
+
+	message := fmt.Sprintf("invalid %s %q", name, value)
+	if suggestion := closestValue(value, c.enum); suggestion != "" {
+		message += fmt.Sprintf(", did you mean %q?", suggestion)
+	}
+	return &keyError{path: path, err: fmt.Errorf("%s (use %s)", message, joinChoices(c.enum))}
+}
+
+// checkCommand checks a setting that takes one of names or a command.
+// Anything but a typo of a name is taken as a command, so bare commands
+// such as "python3" work; a typo is an error because running it would
+// only fail later with "command not found".
+func checkCommand(value, path, name string, names []string) error {
+	if strings.Contains(value, "{script}") || strings.ContainsAny(strings.TrimSpace(value), " \t") {
+		return nil
+	}
+	for _, n := range names {
+		if isTypo(value, n) {
+			return &keyError{path: path, err: fmt.Errorf("invalid %s %q, did you mean %q? (use %s or a command such as \"runner {script}\")",
+				name, value, n, strings.Join(names, ", "))}
+		}
+	}
+	return nil
+}
+
+// isTypo reports whether value is one edit, or a swap of two neighbouring
+// characters, away from name
+func isTypo(value, name string) bool {
+	value, name = strings.ToLower(value), strings.ToLower(name)
+	if editDistance(value, name) <= 1 {
+		return true
+	}
+	if len(value) != len(name) {
+		return false
+	}
+	for i := 0; i+1 < len(value); i++ {
+		if value[i] != name[i] {
+			return value[i] == name[i+1] && value[i+1] == name[i] && value[i+2:] == name[i+2:]
+		}
+	}
+	return false
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// joinChoices lists values as "a, b or c"
+func joinChoices(values []string) string {
+	if len(values) < 2 {
+		return strings.Join(values, "")
+	}
+	return strings.Join(values[:len(values)-1], ", ") + " or " + values[len(values)-1]
+}
+
+// validateHealthProbe checks that a probe has what its type needs
+func validateHealthProbe(probe *types.HealthProbe) error {
+	switch probe.Type {
+	case types.ProbeHTTP:
+		u, err := url.Parse(probe.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &keyError{path: "healthProbe.url", err: fmt.Errorf("invalid healthProbe: http probes need an http(s) url, got %q", probe.URL)}
+		}
+	case types.ProbeTCP:
+		if _, _, err := net.SplitHostPort(probe.Address); err != nil {
+			return &keyError{path: "healthProbe.address", err: fmt.Errorf("invalid healthProbe: tcp probes need a host:port address, got %q", probe.Address)}
+		}
+	case types.ProbeCommand:
+		if probe.Command == "" {
+			return &keyError{path: "healthProbe", err: fmt.Errorf("invalid healthProbe: command probes need a command")}
+		}
+	default:
+		return &keyError{path: "healthProbe.type", err: fmt.Errorf("invalid healthProbe: unknown type %q (use http, tcp or command)", probe.Type)}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *types.FileWatcherConfig)
+		path   string // path of the setting at fault, "" if valid
+		want   string
+	}{
+		{"defaults", func(cfg *types.FileWatcherConfig) {}, "", ""},
+		{"negative number", func(cfg *types.FileWatcherConfig) { cfg.RestartDelay = -1 },
+			"restartDelay", "invalid restartDelay -1: must be at least 0"},
+		{"below minimum", func(cfg *types.FileWatcherConfig) { cfg.PollingInterval = 0 },
+			"pollingInterval", "invalid pollingInterval 0: must be at least 1"},
+		{"enum with suggestion", func(cfg *types.FileWatcherConfig) { cfg.DebounceEdge = "trailng" },
+			"debounceEdge", `invalid debounceEdge "trailng", did you mean "trailing"? (use trailing, leading or both)`},
+		{"rule action", func(cfg *types.FileWatcherConfig) {
+			cfg.Rules = []types.WatchRule{{Pattern: "a"}, {Pattern: "b", Action: "reboot"}}
+		}, "rules[1].action", `invalid rules[1] "b": unknown action "reboot" (use restart, ignore, run-command or notify-only)`},
+		{"above maximum", func(cfg *types.FileWatcherConfig) {
+			cfg.HealthProbe = &types.HealthProbe{Type: types.ProbeHTTP, URL: "http://localhost:3000", ExpectStatus: 600}
+		}, "healthProbe.expectStatus", "invalid healthProbe.expectStatus 600: must be at most 599"},
+		{"runner name", func(cfg *types.FileWatcherConfig) { cfg.TypeScriptRunner = "tsx" }, "", ""},
+		{"runner template", func(cfg *types.FileWatcherConfig) { cfg.TypeScriptRunner = "esrun {script}" }, "", ""},
+		{"unknown runner", func(cfg *types.FileWatcherConfig) { cfg.TypeScriptRunner = "tsxx" },
+			"typescriptRunner", `invalid typescriptRunner "tsxx", did you mean "tsx"? (use bun, deno, node, ts-node, tsx or a command such as "runner {script}")`},
+		{"runners names and templates", func(cfg *types.FileWatcherConfig) {
+			cfg.Runners = map[string]string{".ts": "bun", ".py": "python3 -u {script}"}
+		}, "", ""},
+		{"unknown runners value", func(cfg *types.FileWatcherConfig) {
+			cfg.Runners = map[string]string{".py": "python3 {script}", ".ts": "bnu"}
+		}, "runners..ts", `invalid runners[".ts"] "bnu", did you mean "bun"? (use bun, deno, node, ts-node, tsx or a command such as "runner {script}")`},
+		{"bare runners commands", func(cfg *types.FileWatcherConfig) {
+			cfg.Runners = map[string]string{".sh": "bash", ".py": "python3", ".lua": "lua", ".zsh": "sh"}
+		}, "", ""},
+		{"bare runner command", func(cfg *types.FileWatcherConfig) { cfg.TypeScriptRunner = "esrun" }, "", ""},
+		{"swapped runner letters", func(cfg *types.FileWatcherConfig) { cfg.TypeScriptRunner = "ts-ndoe" },
+			"typescriptRunner", `invalid typescriptRunner "ts-ndoe", did you mean "ts-node"? (use bun, deno, node, ts-node, tsx or a command such as "runner {script}")`},
+		{"runner name in capitals", func(cfg *types.FileWatcherConfig) { cfg.Runners = map[string]string{".ts": "Bun"} },
+			"runners..ts", `invalid runners[".ts"] "Bun", did you mean "bun"? (use bun, deno, node, ts-node, tsx or a command such as "runner {script}")`},
+		{"empty runners value", func(cfg *types.FileWatcherConfig) { cfg.Runners = map[string]string{".ts": ""} },
+			"runners..ts", `invalid runners[".ts"]: runner is empty`},
+	}
+
+	for _, tt := range tests {
+		cfg := &types.FileWatcherConfig{PollingInterval: 100, HealthCheckInterval: 30}
+		tt.modify(cfg)
+
+		err := validateConfig(cfg)
+		if tt.path == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+
+		var ke *keyError
+		if !errors.As(err, &ke) {
+			t.Errorf("%s: error = %v, want an error for %s", tt.name, err, tt.path)
+			continue
+		}
+		if ke.path != tt.path || err.Error() != tt.want {
+			t.Errorf("%s: error for %s = %q, want for %s %q", tt.name, ke.path, err, tt.path, tt.want)
+		}
+	}
+}
+
+// testDefaults are the defaults LoadConfig needs to pass validation
+var testDefaults = []Setting{
+	{Key: "pollingInterval", Value: "100", Source: "default"},
+	{Key: "healthCheckInterval", Value: "30", Source: "default"},
+}
+
+func TestLoadConfigLocatesBadValues(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string // error after the file name
+	}{
+		{ConfigFileName, "{\n  \"script\": \"a.js\",\n  \"runners\": {\n    \".py\": \"python3\",\n    \".ts\": \"tsxx\"\n  }\n}\n",
+			`:5:5: invalid runners[".ts"] "tsxx", did you mean "tsx"? (use bun, deno, node, ts-node, tsx or a command such as "runner {script}")`},
+		{YAMLConfigFileName, "script: a.js\nrunners:\n  .ts: tsxx\n",
+			`:3:3: invalid runners[".ts"] "tsxx", did you mean "tsx"? (use bun, deno, node, ts-node, tsx or a command such as "runner {script}")`},
+		{TOMLConfigFileName, "script = \"a.js\"\n\n[runners]\n\".ts\" = \"tsxx\"\n",
+			`:4:1: invalid runners[".ts"] "tsxx", did you mean "tsx"? (use bun, deno, node, ts-node, tsx or a command such as "runner {script}")`},
+		{ConfigFileName, "{\"script\": \"a.js\",\n  \"debounceEdge\": \"trailng\"}",
+			`:2:3: invalid debounceEdge "trailng", did you mean "trailing"? (use trailing, leading or both)`},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		file := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(file, []byte(tt.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := LoadConfig(testDefaults, nil, dir)
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+			continue
+		}
+		if want := file + tt.want; err.Error() != want {
+			t.Errorf("%s: error = %q, want %q", tt.name, err, want)
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"quickdev/internal/config"
 	"quickdev/internal/process"
@@ -18,12 +22,16 @@ const Version = "1.0.0"
 
 var (
 	scriptFlag            = flag.String("script", "", "Path to the script to run")
+	execFlag             = flag.String("exec", "", "Command line to run instead of a script (e.g. \"go run ./cmd/api\")")
+	buildFlag            = flag.String("build", "", "Command to run before every (re)start; the process only restarts if it succeeds")
 	watchFlag            = flag.String("watch", ".", "Directories to watch (comma-separated)")
-	ignoreFlag           = flag.String("ignore", "node_modules,dist,.git", "Directories to ignore (comma-separated)")
+	ignoreFlag           = flag.String("ignore", "node_modules,dist,.git", "Directories to ignore, added to the config file's (comma-separated)")
 	extFlag             = flag.String("ext", ".js,.ts,.jsx,.tsx", "File extensions to watch (comma-separated)")
 	debounceFlag        = flag.Int("debounce", 250, "Debounce time in milliseconds")
+	debounceMaxWaitFlag = flag.Int("debounce-max-wait", 2000, "Longest a stream of changes can delay a restart, in milliseconds (0 for no limit)")
+	debounceEdgeFlag    = flag.String("debounce-edge", "", "When to react to a burst of changes: trailing, leading or both (default trailing)")
 	restartDelayFlag    = flag.Int("restart-delay", 100, "Delay before restart in milliseconds")
-	maxRestartsFlag     = flag.Int("max-restarts", 0, "Maximum number of restarts (0 for unlimited)")
+	maxRestartsFlag     = flag.Int("max-restarts", 5, "Maximum number of consecutive crash restarts (0 for unlimited)")
 	resetAfterFlag      = flag.Int("reset-after", 60000, "Reset restart count after X milliseconds")
 	gracefulFlag        = flag.Bool("graceful", true, "Use graceful shutdown")
 	gracefulTimeoutFlag = flag.Int("graceful-timeout", 5, "Graceful shutdown timeout in seconds")
@@ -35,6 +43,7 @@ var (
 	hashingFlag         = flag.Bool("hash", true, "Enable file hashing")
 	clearScreenFlag     = flag.Bool("clear", true, "Clear screen on restart")
 	ignoreFileFlag      = flag.String("ignore-file", "", "Custom ignore file")
+	gitignoreFlag       = flag.Bool("gitignore", false, "Also respect .gitignore files")
 	watchDotFlag        = flag.Bool("watch-dot", false, "Watch dot files")
 	maxFileSizeFlag     = flag.Int("max-size", 10, "Maximum file size in MB")
 	excludeEmptyFlag    = flag.Bool("exclude-empty", true, "Exclude empty files")
@@ -42,86 +51,136 @@ var (
 	healthCheckFlag     = flag.Bool("health", true, "Enable health checking")
 	healthIntervalFlag  = flag.Int("health-interval", 30, "Health check interval in seconds")
 	memoryLimitFlag     = flag.Int("memory", 500, "Memory limit in MB")
+	redetectRunnerFlag  = flag.Bool("redetect-runner", false, "Resolve the script runner again on every restart")
+	offlineFlag         = flag.Bool("offline", false, "Never use \"npx -y\" to fetch a missing runner")
+	memoryActionFlag    = flag.String("memory-action", "", "Action when the memory limit is exceeded: restart or kill (default restart)")
+	explainFlag         = flag.Bool("explain", false, "With the config command, show where each setting comes from")
 )
 
+// flagKeys maps command line flags to the config keys they set
+var flagKeys = map[string]string{
+	"script":            "script",
+	"exec":              "exec",
+	"build":             "build",
+	"watch":             "watch",
+	"ignore":            "ignore",
+	"ext":               "extensions",
+	"debounce":          "debounceMs",
+	"debounce-max-wait": "debounceMaxWait",
+	"debounce-edge":     "debounceEdge",
+	"restart-delay":     "restartDelay",
+	"max-restarts":      "maxRestarts",
+	"reset-after":       "resetRestartsAfter",
+	"graceful":          "gracefulShutdown",
+	"graceful-timeout":  "gracefulShutdownTimeout",
+	"polling":           "usePolling",
+	"polling-interval":  "pollingInterval",
+	"follow-symlinks":   "followSymlinks",
+	"batch":             "batchChanges",
+	"batch-timeout":     "batchTimeout",
+	"hash":              "enableHashing",
+	"clear":             "clearScreen",
+	"ignore-file":       "ignoreFile",
+	"gitignore":         "useGitignore",
+	"watch-dot":         "watchDotFiles",
+	"max-size":          "maxFileSize",
+	"exclude-empty":     "excludeEmptyFiles",
+	"parallel":          "parallelProcessing",
+	"health":            "healthCheck",
+	"health-interval":   "healthCheckInterval",
+	"memory":            "memoryLimit",
+	"memory-action":     "memoryLimitAction",
+	"redetect-runner":   "redetectRunner",
+	"offline":           "offline",
+}
+
 func main() {
 	flag.Parse()
 
-	if *scriptFlag == "" {
-		fmt.Println(utils.Error("Error: script path is required"))
+	// An optional command comes first, flags may follow it
+	command := flag.Arg(0)
+	if command != "" {
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	switch command {
+	case "", "config":
+	case "schema":
+		// The schema does not depend on any config, which may be broken
+		printSchema()
+		return
+	default:
+		fmt.Printf("%s unknown command %q\n", utils.Error("Error:"), command)
 		flag.Usage()
-		os.Exit(1)
+		os.Exit(2)
 	}
 
-	// Get absolute path of script
-	scriptPath, err := filepath.Abs(*scriptFlag)
-	if err != nil {
-		fmt.Printf("%s %v\n", utils.Error("Error resolving script path:"), err)
-		os.Exit(1)
+	// Look for the config file next to the script, or in the current
+	// directory and its parents
+	var scriptPath, searchDir string
+	if *scriptFlag != "" {
+		// Get absolute path of script
+		absScript, err := filepath.Abs(*scriptFlag)
+		if err != nil {
+			fmt.Printf("%s %v\n", utils.Error("Error resolving script path:"), err)
+			os.Exit(1)
+		}
+		scriptPath = absScript
+
+		// Find project root (directory containing package.json or parent of script)
+		searchDir = findProjectRoot(scriptPath)
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Printf("%s %v\n", utils.Error("Error resolving working directory:"), err)
+			os.Exit(1)
+		}
+		searchDir = cwd
 	}
 
-	// Find project root (directory containing package.json or parent of script)
-	projectRoot := findProjectRoot(scriptPath)
-
-	// Create initial config from CLI args
-	cliConfig := &types.FileWatcherConfig{
-		Enabled:                true,
-		WatchPaths:            strings.Split(*watchFlag, ","),
-		IgnorePaths:           strings.Split(*ignoreFlag, ","),
-		Extensions:            strings.Split(*extFlag, ","),
-		GracefulShutdown:      *gracefulFlag,
-		GracefulShutdownTimeout: *gracefulTimeoutFlag,
-		MaxRestarts:           *maxRestartsFlag,
-		ResetRestartsAfter:    *resetAfterFlag,
-		RestartDelay:          *restartDelayFlag,
-		BatchChanges:          *batchChangesFlag,
-		BatchTimeout:          *batchTimeoutFlag,
-		EnableFileHashing:     *hashingFlag,
-		UsePolling:            *pollingFlag,
-		PollingInterval:       *pollingIntervalFlag,
-		FollowSymlinks:        *followSymlinksFlag,
-		WatchDotFiles:         *watchDotFlag,
-		CustomIgnoreFile:      *ignoreFileFlag,
-		ParallelProcessing:    *parallelFlag,
-		MemoryLimit:           *memoryLimitFlag,
-		MaxFileSize:           *maxFileSizeFlag,
-		ExcludeEmptyFiles:     *excludeEmptyFlag,
-		DebounceMs:            *debounceFlag,
-		HealthCheck:           *healthCheckFlag,
-		HealthCheckInterval:   *healthIntervalFlag,
-		ClearScreen:           *clearScreenFlag,
-	}
+	// Flag defaults are the lowest layer; only flags the user set
+	// override the config file and environment
+	var defaults, cli []config.Setting
+	defaults = append(defaults, config.Setting{Key: "enabled", Value: "true", Source: "default"})
+	flag.VisitAll(func(f *flag.Flag) {
+		if key, ok := flagKeys[f.Name]; ok {
+			defaults = append(defaults, config.Setting{Key: key, Value: f.DefValue, Source: "default"})
+		}
+	})
+	flag.Visit(func(f *flag.Flag) {
+		key, ok := flagKeys[f.Name]
+		if !ok {
+			return
+		}
+		value := f.Value.String()
+		if f.Name == "script" {
+			value = scriptPath
+		}
+		cli = append(cli, config.Setting{Key: key, Value: value, Source: "-" + f.Name})
+	})
 
 	// Load and merge configuration from files
-	finalConfig, err := config.LoadConfig(cliConfig, projectRoot)
+	loader := &configLoader{defaults: defaults, cli: cli, searchDir: searchDir}
+	finalConfig, err := config.LoadConfig(defaults, cli, searchDir)
 	if err != nil {
 		fmt.Printf("%s %v\n", utils.Error("Error loading configuration:"), err)
 		os.Exit(1)
 	}
 
-	// Normalize paths to absolute
-	for i, path := range finalConfig.WatchPaths {
-		// Skip empty paths
-		if path == "" {
-			continue
-		}
-
-		// Join with project root if path is relative
-		fullPath := path
-		if !filepath.IsAbs(path) {
-			fullPath = filepath.Join(projectRoot, path)
-		}
+	if command == "config" {
+		printConfig(finalConfig, *explainFlag)
+		return
+	}
 
-		// Convert to absolute path
-		absPath, err := filepath.Abs(fullPath)
-		if err == nil {
-			finalConfig.WatchPaths[i] = absPath
-			// fmt.Printf("Normalized watch path: %s -> %s\n", path, absPath)
-		} else {
-			fmt.Printf("Error normalizing path %s: %v\n", path, err)
-		}
+	scriptPath = finalConfig.Script
+	if scriptPath == "" && finalConfig.Exec == "" {
+		fmt.Println(utils.Error("Error: " + errNothingToRun.Error()))
+		flag.Usage()
+		os.Exit(1)
 	}
 
+	// Normalize paths to absolute
+	normalizeWatchPaths(finalConfig)
+
 	// Print watch configuration
 	// fmt.Printf("\nWatch Configuration:\n")
 	// fmt.Printf("Project Root: %s\n", projectRoot)
@@ -135,6 +194,11 @@ func main() {
 	// Create file watcher
 	fw := watcher.NewFileWatcher(finalConfig)
 
+	// Shut down cleanly instead of orphaning the child, also while it is
+	// still starting; SIGHUP reloads the configuration
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
 	// Start the watcher first
 	if err := fw.Start(); err != nil {
 		fmt.Printf("%s %v\n", utils.Error("Error starting watcher:"), err)
@@ -144,23 +208,90 @@ func main() {
 	// Print initial status
 	printStatus(finalConfig)
 
-	// Start the process
-	if err := pm.Start(); err != nil {
-		fmt.Printf("%s %v\n", utils.Error("Error starting process:"), err)
-		os.Exit(1)
+	// Start the process; builds and hooks can take a while, so keep
+	// listening for signals
+	started := make(chan error, 1)
+	go func() {
+		started <- pm.Start()
+	}()
+	reloadPending := false
+	for starting := true; starting; {
+		select {
+		case err := <-started:
+			if err != nil {
+				fmt.Printf("%s %v\n", utils.Error("Error starting process:"), err)
+				os.Exit(1)
+			}
+			starting = false
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				// Reload once the process is up
+				reloadPending = true
+				continue
+			}
+			shutdown(sig, fw, pm)
+		}
+	}
+
+	rules := config.NewRuleSet(finalConfig.Rules, finalConfig.ProjectRoot)
+	if reloadPending {
+		finalConfig, rules = loader.reload("SIGHUP", true, finalConfig, rules, fw, pm)
 	}
 
 	// Main event loop
 	for {
 		select {
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				finalConfig, rules = loader.reload("SIGHUP", true, finalConfig, rules, fw, pm)
+				continue
+			}
+			shutdown(sig, fw, pm)
+		case source := <-fw.GetReloadChannel():
+			finalConfig, rules = loader.reload(filepath.Base(source)+" changed", false, finalConfig, rules, fw, pm)
 		case event := <-fw.GetChangeChannel():
-			handleFileChange(event, pm)
+			handleFileChange(event, pm, rules)
+		case batch := <-fw.GetBatchChannel():
+			handleBatchChange(batch, pm, rules)
 		case err := <-fw.GetErrorChannel():
 			fmt.Printf("%s %v\n", utils.Error("Error:"), err)
 		}
 	}
 }
 
+// shutdown stops the child and the watcher, prints the final statistics and
+// exits with the child's exit code
+func shutdown(sig os.Signal, fw *watcher.FileWatcher, pm *process.ProcessManager) {
+	fmt.Printf("\n%s %s\n", utils.Warning("Received"), sig)
+	fmt.Println(utils.Dimmed("Stopping process..."))
+
+	// A second signal skips the graceful shutdown
+	signal.Reset(os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	if err := pm.Stop(); err != nil {
+		fmt.Printf("%s %v\n", utils.Error("Error stopping process:"), err)
+	}
+	if err := fw.Stop(); err != nil {
+		fmt.Printf("%s %v\n", utils.Error("Error stopping watcher:"), err)
+	}
+
+	stats := pm.GetRestartStats()
+	printRestartStats(stats)
+	os.Exit(exitCode(stats, sig))
+}
+
+// exitCode is the child's last exit code, or for a child killed by a
+// signal, the code shells report for sig
+func exitCode(stats *types.RestartStats, sig os.Signal) int {
+	if stats.LastExitCode >= 0 {
+		return stats.LastExitCode
+	}
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
 // findProjectRoot looks for package.json to determine project root
 func findProjectRoot(scriptPath string) string {
 	dir := filepath.Dir(scriptPath)
@@ -173,20 +304,96 @@ func findProjectRoot(scriptPath string) string {
 	return filepath.Dir(scriptPath)
 }
 
-func handleFileChange(event types.FileEvent, pm *process.ProcessManager) {
+func handleFileChange(event types.FileEvent, pm *process.ProcessManager, rules *config.RuleSet) {
+	actions := &changeActions{rules: rules}
+	action := actions.add(event.Path)
+	if action == types.RuleActionIgnore {
+		return
+	}
+
 	// Print change details
-	fmt.Printf("\n%s %s\n", utils.Info("File changed:"), utils.Path(event.Path))
+	fmt.Printf("\n%s %s%s\n", utils.Info("File changed:"), utils.Path(event.Path), actionNote(action))
 	fmt.Printf("%s %s\n", utils.Section("Operation:"), event.Operation)
 	fmt.Printf("%s %s\n", utils.Section("Time:"), event.Time.Format("15:04:05"))
 
-	// Restart the process
-	if err := pm.Restart(); err != nil {
-		fmt.Printf("%s %v\n", utils.Error("Error restarting process:"), err)
+	actions.run(pm)
+}
+
+func handleBatchChange(batch types.BatchChangeEvent, pm *process.ProcessManager, rules *config.RuleSet) {
+	actions := &changeActions{rules: rules}
+	var changes []types.FileChangeEvent
+	var notes []string
+	for _, change := range batch.Changes {
+		action := actions.add(change.FullPath)
+		if action == types.RuleActionIgnore {
+			continue
+		}
+		changes = append(changes, change)
+		notes = append(notes, actionNote(action))
+	}
+	if len(changes) == 0 {
 		return
 	}
 
-	// Print restart success
-	fmt.Printf("%s\n", utils.Success("Process restarted successfully"))
+	// Print the whole batch, then act once for all of it
+	fmt.Printf("\n%s %d\n", utils.Info("Files changed:"), len(changes))
+	for i, change := range changes {
+		fmt.Printf("  %s %s%s\n", utils.Dimmed(strings.ToLower(change.Type)), utils.Path(change.RelativePath), notes[i])
+	}
+	fmt.Printf("%s %s\n", utils.Section("Time:"), batch.Timestamp.Format("15:04:05"))
+
+	actions.run(pm)
+}
+
+// changeActions collects what the watch rules ask for across a set of
+// changed files, so a batch runs each command and restarts at most once
+type changeActions struct {
+	rules    *config.RuleSet
+	restart  bool
+	commands []types.WatchRule
+}
+
+// add records the action for a changed path and returns it. Paths without
+// a matching rule restart the process.
+func (a *changeActions) add(path string) string {
+	rule, ok := a.rules.Match(path)
+	if !ok {
+		a.restart = true
+		return types.RuleActionRestart
+	}
+
+	switch rule.Action {
+	case types.RuleActionRestart:
+		a.restart = true
+	case types.RuleActionRunCommand:
+		for i := range a.commands {
+			if a.commands[i].Command == rule.Command {
+				a.commands[i].Restart = a.commands[i].Restart || rule.Restart
+				return rule.Action
+			}
+		}
+		a.commands = append(a.commands, rule)
+	}
+	return rule.Action
+}
+
+// run hands the collected commands and restart to the process manager.
+// They run in the background so new changes and shutdown can cancel them.
+func (a *changeActions) run(pm *process.ProcessManager) {
+	if a.restart || len(a.commands) > 0 {
+		pm.RequestActions(a.commands, a.restart)
+	}
+}
+
+// actionNote describes a rule action next to a changed file
+func actionNote(action string) string {
+	switch action {
+	case types.RuleActionNotifyOnly:
+		return utils.Dimmed(" (notify only)")
+	case types.RuleActionRunCommand:
+		return utils.Dimmed(" (run command)")
+	}
+	return ""
 }
 
 func loadIgnoreFile(path string) ([]string, error) {
@@ -209,15 +416,79 @@ func loadIgnoreFile(path string) ([]string, error) {
 	return patterns, nil
 }
 
+// printSchema prints the JSON Schema of config files
+func printSchema() {
+	data, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		fmt.Printf("%s %v\n", utils.Error("Error:"), err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
+
+// printConfig prints the effective configuration as JSON or, when
+// explaining, each setting with the layer it came from
+func printConfig(cfg *types.FileWatcherConfig, explain bool) {
+	if !explain {
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			fmt.Printf("%s %v\n", utils.Error("Error:"), err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	settings := config.Explain(cfg)
+	width := 0
+	for _, setting := range settings {
+		if len(setting.Key) > width {
+			width = len(setting.Key)
+		}
+	}
+
+	fmt.Printf("\n%s\n", utils.Header("Effective configuration"))
+	fmt.Println(utils.Dimmed("================================"))
+	if cfg.ConfigFile != "" {
+		fmt.Printf("%s %s\n", utils.Section("Config:"), utils.Path(cfg.ConfigFile))
+	}
+	fmt.Printf("%s %s\n\n", utils.Section("Project root:"), utils.Path(cfg.ProjectRoot))
+	for _, setting := range settings {
+		origin := setting.Origin.Layer
+		if setting.Origin.Source != "" && setting.Origin.Source != setting.Origin.Layer {
+			origin += " " + setting.Origin.Source
+		}
+		fmt.Printf("%s %s %s\n", utils.Section(fmt.Sprintf("%-*s", width, setting.Key)), setting.Value, utils.Dimmed("("+origin+")"))
+	}
+}
+
 func printStatus(config *types.FileWatcherConfig) {
 	fmt.Printf("\n%s\n", utils.Header("Nehonix quickdev"))
 	fmt.Println(utils.Dimmed("================================"))
 
+	if config.Exec != "" {
+		fmt.Printf("%s %s\n", utils.Section("Command:"), utils.Command(config.Exec))
+	} else {
+		fmt.Printf("%s %s\n", utils.Section("Script:"), utils.Path(config.Script))
+	}
+	if config.ConfigFile != "" {
+		fmt.Printf("%s %s\n", utils.Section("Config:"), utils.Path(config.ConfigFile))
+	}
+	if config.Build != "" {
+		fmt.Printf("%s %s\n", utils.Section("Build:"), utils.Command(config.Build))
+	}
 	fmt.Printf("%s %s\n", utils.Section("Watching:"), utils.Path(strings.Join(config.WatchPaths, ", ")))
 	//print project github link
 	fmt.Printf("%s %s\n", utils.Section("Github:"), "https://github.com/nehonix/quickdev")
-	fmt.Printf("%s %s\n", utils.Section("Ignoring:"), utils.Path(strings.Join(config.IgnorePaths, ", ")))
+	ignoring := utils.Path(strings.Join(config.IgnorePaths, ", "))
+	if len(config.IgnoreRules) > 0 {
+		ignoring += utils.Dimmed(fmt.Sprintf(" (+%d rules from ignore files)", len(config.IgnoreRules)))
+	}
+	fmt.Printf("%s %s\n", utils.Section("Ignoring:"), ignoring)
 	fmt.Printf("%s %s\n", utils.Section("Extensions:"), utils.Path(strings.Join(config.Extensions, ", ")))
+	if len(config.WatchAlways) > 0 {
+		fmt.Printf("%s %s\n", utils.Section("Always watching:"), utils.Path(strings.Join(config.WatchAlways, ", ")))
+	}
 
 	features := getEnabledFeatures(config)
 	fmt.Printf("%s %s\n", utils.Section("Features:"), features)
@@ -238,6 +509,19 @@ func printStatus(config *types.FileWatcherConfig) {
 	fmt.Printf("%s\n\n", utils.Dimmed("Press Ctrl+C to exit"))
 }
 
+func printRestartStats(stats *types.RestartStats) {
+	fmt.Printf("\n%s\n", utils.Header("Session statistics"))
+	fmt.Println(utils.Dimmed("================================"))
+	fmt.Printf("%s %d\n", utils.Section("Restarts:"), stats.TotalRestarts)
+	if len(stats.RestartHistory) > 0 {
+		fmt.Printf("%s %s\n", utils.Section("Average uptime:"), stats.AverageUptime.Round(time.Millisecond))
+		fmt.Printf("%s %s\n", utils.Section("Longest uptime:"), stats.LongestUptime.Round(time.Millisecond))
+		fmt.Printf("%s %s\n", utils.Section("Shortest uptime:"), stats.ShortestUptime.Round(time.Millisecond))
+		fmt.Printf("%s %d\n", utils.Section("Last exit code:"), stats.LastExitCode)
+	}
+	fmt.Println(utils.Dimmed("================================"))
+}
+
 func getEnabledFeatures(config *types.FileWatcherConfig) string {
 	var features []string
 
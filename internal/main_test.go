@@ -0,0 +1,216 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"quickdev/internal/config"
+	"quickdev/internal/types"
+)
+
+// runMainEnv makes the test binary run quickdev instead of the tests
+const runMainEnv = "QUICKDEV_TEST_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// startQuickdev runs quickdev in dir with a config file holding config
+func startQuickdev(t *testing.T, dir, config string) *exec.Cmd {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs POSIX signals and sh")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "quickdev.config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return cmd
+}
+
+// waitForFile waits until path exists
+func waitForFile(t *testing.T, path string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", filepath.Base(path))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// waitExit waits for quickdev to exit and returns its exit code
+func waitExit(t *testing.T, cmd *exec.Cmd, within time.Duration) int {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		return 0
+	case <-time.After(within):
+		t.Fatalf("quickdev still running %s after the signal", within)
+		return -1
+	}
+}
+
+func TestShutdownSignals(t *testing.T) {
+	tests := []struct {
+		name string
+		sig  syscall.Signal
+		exec string
+		want int
+	}{
+		{"child exits on SIGTERM", syscall.SIGTERM,
+			`sh -c 'trap \"echo > stopped; exit 0\" TERM; echo > started; while true; do sleep 0.1; done'`, 0},
+		{"child exits with a code", syscall.SIGINT,
+			`sh -c 'trap \"echo > stopped; exit 3\" TERM; echo > started; while true; do sleep 0.1; done'`, 3},
+		{"child killed by the signal", syscall.SIGTERM,
+			`sh -c 'echo > started; exec sleep 30'`, 128 + int(syscall.SIGTERM)},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		cmd := startQuickdev(t, dir, `{"exec": "`+tt.exec+`", "clearScreen": false}`)
+		waitForFile(t, filepath.Join(dir, "started"))
+
+		cmd.Process.Signal(tt.sig)
+		if got := waitExit(t, cmd, 10*time.Second); got != tt.want {
+			t.Errorf("%s: exit code %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestShutdownForwardsSignal(t *testing.T) {
+	dir := t.TempDir()
+	cmd := startQuickdev(t, dir, `{"exec": "sh -c 'trap \"echo > stopped; exit 0\" TERM; echo > started; while true; do sleep 0.1; done'"}`)
+	waitForFile(t, filepath.Join(dir, "started"))
+
+	cmd.Process.Signal(syscall.SIGINT)
+	waitExit(t, cmd, 10*time.Second)
+	if _, err := os.Stat(filepath.Join(dir, "stopped")); err != nil {
+		t.Error("the child did not get SIGTERM")
+	}
+}
+
+func TestShutdownDuringStartup(t *testing.T) {
+	// The build never finishes, so the signal arrives before the child runs
+	dir := t.TempDir()
+	cmd := startQuickdev(t, dir, `{"build": "echo > building; sleep 30", "exec": "echo > started"}`)
+	waitForFile(t, filepath.Join(dir, "building"))
+
+	cmd.Process.Signal(syscall.SIGTERM)
+	waitExit(t, cmd, 5*time.Second)
+	if _, err := os.Stat(filepath.Join(dir, "started")); err == nil {
+		t.Error("the child started after the signal")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		lastExitCode int
+		sig          os.Signal
+		want         int
+	}{
+		{0, syscall.SIGTERM, 0},
+		{3, os.Interrupt, 3},
+		{-1, syscall.SIGTERM, 128 + int(syscall.SIGTERM)},
+		{-1, os.Interrupt, 128 + int(syscall.SIGINT)},
+	}
+
+	for _, tt := range tests {
+		stats := &types.RestartStats{LastExitCode: tt.lastExitCode}
+		if got := exitCode(stats, tt.sig); got != tt.want {
+			t.Errorf("exitCode(%d, %v) = %d, want %d", tt.lastExitCode, tt.sig, got, tt.want)
+		}
+	}
+}
+
+func TestChangeActions(t *testing.T) {
+	root, err := filepath.Abs("project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := config.NewRuleSet([]types.WatchRule{
+		{Pattern: "schema.prisma", Action: types.RuleActionRunCommand, Command: "npx prisma generate"},
+		{Pattern: "*.graphql", Action: types.RuleActionRunCommand, Command: "npm run codegen", Restart: true},
+		{Pattern: "*.gql", Action: types.RuleActionRunCommand, Command: "npm run codegen"},
+		{Pattern: "*.md", Action: types.RuleActionNotifyOnly},
+		{Pattern: "*.snap", Action: types.RuleActionIgnore},
+		{Pattern: "*.ts", Action: types.RuleActionRestart},
+	}, root)
+
+	tests := []struct {
+		name         string
+		paths        []string
+		wantActions  []string
+		wantRestart  bool
+		wantCommands []types.WatchRule
+	}{
+		{"no matching rule restarts", []string{"app.js"},
+			[]string{types.RuleActionRestart}, true, nil},
+		{"restart rule", []string{"app.ts"},
+			[]string{types.RuleActionRestart}, true, nil},
+		{"notify only", []string{"README.md"},
+			[]string{types.RuleActionNotifyOnly}, false, nil},
+		{"ignore", []string{"app.snap"},
+			[]string{types.RuleActionIgnore}, false, nil},
+		{"run command", []string{"schema.prisma"},
+			[]string{types.RuleActionRunCommand}, false,
+			[]types.WatchRule{{Pattern: "schema.prisma", Action: types.RuleActionRunCommand, Command: "npx prisma generate"}}},
+		{"command runs once, restarting if any rule asks", []string{"a.gql", "b.graphql", "README.md"},
+			[]string{types.RuleActionRunCommand, types.RuleActionRunCommand, types.RuleActionNotifyOnly}, false,
+			[]types.WatchRule{{Pattern: "*.gql", Action: types.RuleActionRunCommand, Command: "npm run codegen", Restart: true}}},
+		{"commands and a restart", []string{"schema.prisma", "app.ts"},
+			[]string{types.RuleActionRunCommand, types.RuleActionRestart}, true,
+			[]types.WatchRule{{Pattern: "schema.prisma", Action: types.RuleActionRunCommand, Command: "npx prisma generate"}}},
+	}
+
+	for _, tt := range tests {
+		actions := &changeActions{rules: rules}
+		var got []string
+		for _, path := range tt.paths {
+			got = append(got, actions.add(filepath.Join(root, path)))
+		}
+		if !reflect.DeepEqual(got, tt.wantActions) {
+			t.Errorf("%s: actions = %v, want %v", tt.name, got, tt.wantActions)
+		}
+		if actions.restart != tt.wantRestart {
+			t.Errorf("%s: restart = %v, want %v", tt.name, actions.restart, tt.wantRestart)
+		}
+		if !reflect.DeepEqual(actions.commands, tt.wantCommands) {
+			t.Errorf("%s: commands = %+v, want %+v", tt.name, actions.commands, tt.wantCommands)
+		}
+	}
+}
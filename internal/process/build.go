@@ -0,0 +1,76 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"quickdev/internal/utils"
+)
+
+// ErrBuildFailed is returned by Restart when the build command fails and
+// the running process has been left alone
+var ErrBuildFailed = errors.New("build failed")
+
+// runBuild runs the configured build command, printing its output and a
+// summary. It returns ErrBuildFailed when the build does not exit 0 and
+// the context's error when it was cancelled.
+func (pm *ProcessManager) runBuild(ctx context.Context) error {
+	config := pm.currentConfig()
+	if config.Build == "" {
+		return nil
+	}
+
+	fmt.Printf("%s %s\n", utils.Section("Build:"), utils.Command(config.Build))
+	start := time.Now()
+	err := RunCommand(ctx, config.Build, config.ProjectRoot, "build", 0)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err != nil {
+		pm.buildFailures++
+		fmt.Printf("%s after %s (%v)\n", utils.Error("Build failed"), elapsed, err)
+		if pm.isRunning() {
+			fmt.Printf("%s\n", utils.Dimmed("Keeping the previous process running, fix the error and save again"))
+		} else {
+			fmt.Printf("%s\n", utils.Dimmed("Waiting for file changes"))
+		}
+		return ErrBuildFailed
+	}
+
+	if pm.buildFailures > 0 {
+		fmt.Printf("%s in %s (after %d failed %s)\n", utils.Success("Build succeeded"), elapsed, pm.buildFailures, plural(pm.buildFailures, "build", "builds"))
+	} else {
+		fmt.Printf("%s in %s\n", utils.Success("Build succeeded"), elapsed)
+	}
+	pm.buildFailures = 0
+	return nil
+}
+
+// isRunning reports whether the current process is still running
+func (pm *ProcessManager) isRunning() bool {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.cmd == nil {
+		return false
+	}
+	select {
+	case <-pm.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// plural picks the singular or plural form for n
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}
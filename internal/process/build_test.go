@@ -0,0 +1,85 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"quickdev/internal/types"
+)
+
+func TestBuildStep(t *testing.T) {
+	skipWithoutShell(t)
+
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "ok")
+	pm := NewProcessManager("", &types.FileWatcherConfig{
+		Build:                   "test -f ok",
+		Exec:                    "sleep 30",
+		ProjectRoot:             dir,
+		GracefulShutdownTimeout: 1,
+	})
+	defer pm.Stop()
+
+	// The build passes while the marker exists
+	steps := []struct {
+		buildOk      bool
+		wantErr      error
+		wantReplaced bool
+		wantFailures int
+	}{
+		{true, nil, true, 0},
+		{false, ErrBuildFailed, false, 1},
+		{false, ErrBuildFailed, false, 2},
+		{true, nil, true, 0},
+	}
+
+	// Nothing runs until the first build succeeds
+	if err := pm.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if pm.isRunning() {
+		t.Fatal("process started although the build failed")
+	}
+
+	for i, step := range steps {
+		os.Remove(marker)
+		if step.buildOk {
+			if err := os.WriteFile(marker, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cmd := pm.currentCmd()
+		if err := pm.Restart(); !errors.Is(err, step.wantErr) {
+			t.Errorf("step %d: Restart error = %v, want %v", i+1, err, step.wantErr)
+		}
+		if replaced := pm.currentCmd() != cmd; replaced != step.wantReplaced || !pm.isRunning() {
+			t.Errorf("step %d: replaced = %v and running = %v, want %v and true", i+1, replaced, pm.isRunning(), step.wantReplaced)
+		}
+		if pm.buildFailures != step.wantFailures {
+			t.Errorf("step %d: %d failed builds counted, want %d", i+1, pm.buildFailures, step.wantFailures)
+		}
+	}
+}
+
+func TestBuildCancelled(t *testing.T) {
+	skipWithoutShell(t)
+
+	pm := NewProcessManager("", &types.FileWatcherConfig{
+		Build:       "sleep 30",
+		ProjectRoot: t.TempDir(),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := pm.runBuild(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if pm.buildFailures != 0 {
+		t.Errorf("a cancelled build counted as %d failures", pm.buildFailures)
+	}
+}
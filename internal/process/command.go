@@ -0,0 +1,122 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"quickdev/internal/utils"
+)
+
+// RunCommand runs a shell command in dir and waits for it, prefixing every
+// line it prints with label. The command and everything it started are
+// killed once timeout passes or ctx is cancelled; a zero timeout waits
+// forever.
+func RunCommand(ctx context.Context, command, dir, label string, timeout time.Duration) error {
+	prefix := utils.Dimmed("["+label+"]") + " "
+	stdout := &prefixWriter{out: os.Stdout, prefix: prefix}
+	stderr := &prefixWriter{out: os.Stderr, prefix: prefix}
+
+	err := runShell(ctx, command, dir, stdout, stderr, timeout)
+	stdout.Flush()
+	stderr.Flush()
+	return err
+}
+
+// runShell runs a shell command with the given output, killing its whole
+// process group on timeout or cancellation
+func runShell(ctx context.Context, command, dir string, stdout, stderr io.Writer, timeout time.Duration) error {
+	cmd := shellCommand(command)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%s: %v", command, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var err error
+	select {
+	case err = <-done:
+	case <-expired:
+		killGroup(cmd)
+		<-done
+		err = fmt.Errorf("timed out after %s", timeout)
+	case <-ctx.Done():
+		killGroup(cmd)
+		<-done
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	return nil
+}
+
+// shellCommand runs command through the platform shell so pipes, && and
+// environment variables work as they do in package.json scripts
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// prefixWriter writes complete lines to out, each starting with prefix
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+	buf    bytes.Buffer
+	mutex  sync.Mutex
+}
+
+// Write buffers p and writes out every complete line
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buf.Write(p)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := w.buf.Next(i + 1)
+		if _, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes out a trailing line that did not end in a newline
+func (w *prefixWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.buf.Len() > 0 {
+		fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
@@ -0,0 +1,150 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"quickdev/internal/types"
+	"quickdev/internal/utils"
+)
+
+const (
+	// readyProbeInterval is how often a process is probed until it is ready
+	readyProbeInterval = 500 * time.Millisecond
+
+	// defaultProbeTimeout applies when healthProbe.timeout is not set
+	defaultProbeTimeout = 2 * time.Second
+
+	// defaultFailureThreshold applies when healthProbe.failureThreshold is
+	// not set
+	defaultFailureThreshold = 3
+
+	// defaultStartTimeout applies when healthProbe.startTimeout is not set
+	defaultStartTimeout = 30 * time.Second
+)
+
+// monitorHealth probes a process until it exits. It reports when the
+// process first becomes ready and restarts it once it has failed
+// FailureThreshold probes in a row after being ready, or when it is not
+// ready within StartTimeout.
+func (pm *ProcessManager) monitorHealth(cmd *exec.Cmd, started time.Time, exited <-chan struct{}) {
+	config := pm.currentConfig()
+	probe := config.HealthProbe
+	interval := time.Duration(config.HealthCheckInterval) * time.Second
+	if interval <= 0 {
+		interval = readyProbeInterval
+	}
+	threshold := probe.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+	startTimeout := defaultStartTimeout
+	if probe.StartTimeout > 0 {
+		startTimeout = time.Duration(probe.StartTimeout) * time.Millisecond
+	}
+
+	ready := false
+	failures := 0
+	timer := time.NewTimer(readyProbeInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-exited:
+			return
+		case <-timer.C:
+		}
+
+		err := pm.runProbe(probe)
+		switch {
+		case err == nil && !ready:
+			ready = true
+			fmt.Printf("%s in %s\n", utils.Success("Ready"), time.Since(started).Round(time.Millisecond))
+		case err == nil:
+			if failures > 0 {
+				fmt.Printf("%s\n", utils.Success("Health check passing again"))
+			}
+			failures = 0
+		case ready:
+			// Failures only count once the process has been ready
+			failures++
+			fmt.Printf("%s %v (%d/%d)\n", utils.Warning("Health check failed:"), err, failures, threshold)
+			if failures >= threshold {
+				pm.handleUnhealthy(cmd, fmt.Sprintf("health check failed %d times", failures))
+				return
+			}
+		case time.Since(started) >= startTimeout:
+			fmt.Printf("%s %v\n", utils.Warning("Health check failed:"), err)
+			pm.handleUnhealthy(cmd, fmt.Sprintf("not ready after %s", startTimeout))
+			return
+		}
+
+		if ready {
+			timer.Reset(interval)
+		} else {
+			timer.Reset(readyProbeInterval)
+		}
+	}
+}
+
+// runProbe runs a single health probe
+func (pm *ProcessManager) runProbe(probe *types.HealthProbe) error {
+	timeout := defaultProbeTimeout
+	if probe.Timeout > 0 {
+		timeout = time.Duration(probe.Timeout) * time.Millisecond
+	}
+
+	switch probe.Type {
+	case types.ProbeHTTP:
+		return probeHTTP(probe.URL, probe.ExpectStatus, timeout)
+	case types.ProbeTCP:
+		conn, err := net.DialTimeout("tcp", probe.Address, timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case types.ProbeCommand:
+		return runShell(context.Background(), probe.Command, pm.currentConfig().ProjectRoot, io.Discard, io.Discard, timeout)
+	}
+	return fmt.Errorf("unknown health probe type %q", probe.Type)
+}
+
+// probeHTTP fetches url and checks the response status
+func probeHTTP(url string, expectStatus int, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if expectStatus > 0 {
+		if resp.StatusCode != expectStatus {
+			return fmt.Errorf("%s returned %d, expected %d", url, resp.StatusCode, expectStatus)
+		}
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// handleUnhealthy restarts a process that kept failing its health probe
+// or never became ready. Restarts count as crashes towards MaxRestarts.
+func (pm *ProcessManager) handleUnhealthy(cmd *exec.Cmd, reason string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.stopped || pm.cmd != cmd {
+		return
+	}
+
+	pm.restartCounted(reason)
+}
@@ -0,0 +1,129 @@
+package process
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"quickdev/internal/types"
+)
+
+func TestProbeHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/redirect":
+			w.WriteHeader(http.StatusNotModified)
+		case "/accepted":
+			w.WriteHeader(http.StatusAccepted)
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path         string
+		expectStatus int
+		wantErr      string
+	}{
+		{"/ok", 0, ""},
+		{"/redirect", 0, ""},
+		{"/down", 0, "/down returned 503"},
+		{"/accepted", http.StatusAccepted, ""},
+		{"/ok", http.StatusAccepted, "/ok returned 200, expected 202"},
+		{"/slow", 0, "Client.Timeout exceeded"},
+	}
+
+	for _, tt := range tests {
+		err := probeHTTP(server.URL+tt.path, tt.expectStatus, 100*time.Millisecond)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s expecting %d: unexpected error: %v", tt.path, tt.expectStatus, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s expecting %d: error = %v, want %q", tt.path, tt.expectStatus, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRunProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// A port nothing listens on
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddress := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		probe   types.HealthProbe
+		shell   bool
+		wantErr bool
+	}{
+		{types.HealthProbe{Type: types.ProbeTCP, Address: listener.Addr().String()}, false, false},
+		{types.HealthProbe{Type: types.ProbeTCP, Address: closedAddress}, false, true},
+		{types.HealthProbe{Type: types.ProbeCommand, Command: "true"}, true, false},
+		{types.HealthProbe{Type: types.ProbeCommand, Command: "exit 1"}, true, true},
+		{types.HealthProbe{Type: types.ProbeCommand, Command: "sleep 30", Timeout: 100}, true, true},
+		{types.HealthProbe{Type: "grpc"}, false, true},
+	}
+
+	pm := NewProcessManager("", &types.FileWatcherConfig{ProjectRoot: t.TempDir()})
+	for _, tt := range tests {
+		if tt.shell && runtime.GOOS == "windows" {
+			continue
+		}
+		if err := pm.runProbe(&tt.probe); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: error = %v, want an error: %v", tt.probe, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNotReadyRestarts(t *testing.T) {
+	skipWithoutShell(t)
+
+	pm := NewProcessManager("", &types.FileWatcherConfig{
+		Exec:                    "sleep 30",
+		ProjectRoot:             t.TempDir(),
+		HealthCheck:             true,
+		HealthProbe:             &types.HealthProbe{Type: types.ProbeCommand, Command: "exit 1", StartTimeout: 100},
+		MaxRestarts:             2,
+		ResetRestartsAfter:      60000,
+		GracefulShutdownTimeout: 1,
+	})
+	defer pm.Stop()
+	if err := pm.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Processes that never become ready count as crashes
+	waitFor(t, "the crash loop", pm.inCrashLoop)
+	if pm.isRunning() {
+		t.Error("process still running in the crash loop state")
+	}
+
+	history := pm.GetRestartStats().RestartHistory
+	if len(history) == 0 {
+		t.Fatal("no exits recorded")
+	}
+	for _, entry := range history {
+		if entry.Reason != "not ready after 100ms" {
+			t.Errorf("exit reason %q, want %q", entry.Reason, "not ready after 100ms")
+		}
+	}
+}
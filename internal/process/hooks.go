@@ -0,0 +1,74 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"quickdev/internal/types"
+	"quickdev/internal/utils"
+)
+
+// defaultHookTimeout applies when hooks.timeout is not set
+const defaultHookTimeout = 60 * time.Second
+
+// hookError reports a failed before-hook that aborts a (re)start
+type hookError struct {
+	name string
+	err  error
+}
+
+func (e *hookError) Error() string {
+	return fmt.Sprintf("%s hook failed: %v", e.name, e.err)
+}
+
+func (e *hookError) Unwrap() error {
+	return e.err
+}
+
+// runHooks runs the commands of one hook in the project root, stopping at
+// the first failure
+func (pm *ProcessManager) runHooks(ctx context.Context, name string, commands []string) error {
+	config := pm.currentConfig()
+	timeout := hookTimeout(config)
+	for _, command := range commands {
+		fmt.Printf("%s %s\n", utils.Dimmed(name+":"), utils.Command(command))
+		if err := RunCommand(ctx, command, config.ProjectRoot, name, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hookTimeout is how long a hook or rule command may run
+func hookTimeout(config *types.FileWatcherConfig) time.Duration {
+	if config.Hooks.Timeout > 0 {
+		return time.Duration(config.Hooks.Timeout) * time.Second
+	}
+	return defaultHookTimeout
+}
+
+// runBeforeHooks runs a before-hook and returns a *hookError when it fails
+// and AbortOnFailure is set or ctx was cancelled; otherwise failures are
+// only reported
+func (pm *ProcessManager) runBeforeHooks(ctx context.Context, name string, commands []string) error {
+	err := pm.runHooks(ctx, name, commands)
+	if err == nil {
+		return nil
+	}
+	if pm.currentConfig().Hooks.AbortOnFailure || ctx.Err() != nil {
+		return &hookError{name: name, err: err}
+	}
+	fmt.Printf("%s %v\n", utils.Warning(name+" hook failed, continuing:"), err)
+	return nil
+}
+
+// runAfterHooks runs an after-hook, reporting failures
+func (pm *ProcessManager) runAfterHooks(ctx context.Context, name string, commands []string) {
+	if err := pm.runHooks(ctx, name, commands); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Printf("%s %v\n", utils.Warning(name+" hook failed:"), err)
+	}
+}
@@ -0,0 +1,146 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"quickdev/internal/types"
+)
+
+// readLines returns the lines of file, or nil when it does not exist yet
+func readLines(file string) []string {
+	data, err := os.ReadFile(file)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestHookOrder(t *testing.T) {
+	skipWithoutShell(t)
+
+	dir := t.TempDir()
+	events := filepath.Join(dir, "events")
+	pm := NewProcessManager("", &types.FileWatcherConfig{
+		Exec:                    "sleep 30",
+		ProjectRoot:             dir,
+		GracefulShutdownTimeout: 1,
+		Hooks: types.Hooks{
+			BeforeStart:   []string{"echo beforeStart >> events"},
+			AfterStart:    []string{"echo afterStart >> events"},
+			BeforeRestart: []string{"echo beforeRestart >> events"},
+			AfterExit:     []string{"echo afterExit >> events"},
+		},
+	})
+
+	// afterStart runs in the background, so wait for it before moving on
+	waitForLines := func(count int) {
+		waitFor(t, "the hooks to run", func() bool {
+			return len(readLines(events)) == count
+		})
+	}
+
+	if err := pm.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitForLines(2)
+	if err := pm.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	waitForLines(6)
+	if err := pm.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"beforeStart", "afterStart",
+		"beforeRestart", "afterExit", "beforeStart", "afterStart",
+		"afterExit",
+	}
+	if got := readLines(events); !reflect.DeepEqual(got, want) {
+		t.Errorf("hooks ran in the order %v, want %v", got, want)
+	}
+}
+
+func TestBeforeHookFailure(t *testing.T) {
+	skipWithoutShell(t)
+
+	tests := []struct {
+		name           string
+		hooks          types.Hooks
+		wantRunning    bool
+		wantRestartErr string
+		wantReplaced   bool
+	}{
+		{"beforeStart aborts",
+			types.Hooks{BeforeStart: []string{"exit 1"}, AbortOnFailure: true},
+			false, "", false},
+		{"beforeStart continues",
+			types.Hooks{BeforeStart: []string{"exit 1"}},
+			true, "", true},
+		{"beforeRestart keeps the process",
+			types.Hooks{BeforeRestart: []string{"exit 2"}, AbortOnFailure: true},
+			true, "beforeRestart hook failed: exit 2: exit status 2", false},
+		{"beforeRestart continues",
+			types.Hooks{BeforeRestart: []string{"exit 2"}},
+			true, "", true},
+	}
+
+	for _, tt := range tests {
+		pm := NewProcessManager("", &types.FileWatcherConfig{
+			Exec:                    "sleep 30",
+			ProjectRoot:             t.TempDir(),
+			GracefulShutdownTimeout: 1,
+			Hooks:                   tt.hooks,
+		})
+		if err := pm.Start(); err != nil {
+			t.Errorf("%s: Start: %v", tt.name, err)
+			continue
+		}
+		if pm.isRunning() != tt.wantRunning {
+			t.Errorf("%s: running after Start = %v, want %v", tt.name, pm.isRunning(), tt.wantRunning)
+		}
+		if !tt.wantRunning {
+			pm.Stop()
+			continue
+		}
+
+		cmd := pm.currentCmd()
+		err := pm.Restart()
+		var hookErr *hookError
+		switch {
+		case tt.wantRestartErr == "" && err != nil:
+			t.Errorf("%s: Restart: %v", tt.name, err)
+		case tt.wantRestartErr != "" && (!errors.As(err, &hookErr) || err.Error() != tt.wantRestartErr):
+			t.Errorf("%s: Restart error = %v, want %q", tt.name, err, tt.wantRestartErr)
+		}
+		if replaced := pm.currentCmd() != cmd; replaced != tt.wantReplaced || !pm.isRunning() {
+			t.Errorf("%s: replaced = %v and running = %v, want %v and true", tt.name, replaced, pm.isRunning(), tt.wantReplaced)
+		}
+		pm.Stop()
+	}
+}
+
+func TestHookTimeout(t *testing.T) {
+	skipWithoutShell(t)
+
+	pm := NewProcessManager("", &types.FileWatcherConfig{
+		ProjectRoot: t.TempDir(),
+		Hooks:       types.Hooks{Timeout: 1},
+	})
+
+	started := time.Now()
+	err := pm.runHooks(context.Background(), "beforeStart", []string{"sleep 30", "echo never"})
+	if want := "sleep 30: timed out after 1s"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Errorf("hook ran for %s after its timeout", elapsed)
+	}
+}
@@ -1,45 +1,109 @@
 package process
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"quickdev/internal/config"
 	"quickdev/internal/types"
+	"quickdev/internal/utils"
 )
 
+// maxCrashRestartDelay caps the back-off between automatic restarts
+const maxCrashRestartDelay = 5 * time.Second
+
+// killWait bounds how long a stop waits for the rest of a group it killed
+const killWait = time.Second
+
 // ProcessManager handles the running process
 type ProcessManager struct {
 	config       *types.FileWatcherConfig
+	configMutex  sync.RWMutex // guards config for readers not holding mutex
 	scriptPath   string
+	runners      *RunnerRegistry
+	runnerArgs   []string // Cached runner command line for scriptPath
 	cmd          *exec.Cmd
+	exited       chan struct{} // closed when cmd has exited
+	cleanedUp    chan struct{} // closed once the afterExit hooks of cmd have run
+	handled      chan struct{} // closed once the exit of cmd has been handled
+	stopped      bool
+	stopCtx      context.Context // cancelled by Stop, ends builds and hooks
+	stopAll      context.CancelFunc
+	afterStart   sync.WaitGroup // afterStart hooks still running
 	mutex        sync.Mutex
 	restartStats *types.RestartStats
 	startTime    time.Time
+
+	buildFailures int // Consecutive failed builds
+
+	// Why each process we stopped was stopped, until its exit is handled
+	killed map[*exec.Cmd]string
+
+	// Restart coalescing
+	restartRequests chan struct{}      // holds at most one pending request
+	pendingRestart  bool               // a restart is waiting to run
+	pendingCommands []types.WatchRule  // rule commands waiting to run
+	cancelRestart   context.CancelFunc // cancels the restart in progress
+	restartMutex    sync.Mutex
+
+	// Crash loop detection
+	crashCount       int
+	crashWindowStart time.Time
+	crashLoop        bool
 }
 
 // NewProcessManager creates a new process manager
 func NewProcessManager(scriptPath string, config *types.FileWatcherConfig) *ProcessManager {
+	stopCtx, stopAll := context.WithCancel(context.Background())
 	return &ProcessManager{
 		config:     config,
 		scriptPath: scriptPath,
+		runners:    NewRunnerRegistry(config),
+		stopCtx:    stopCtx,
+		stopAll:    stopAll,
 		restartStats: &types.RestartStats{
 			RestartHistory: make([]types.RestartHistoryEntry, 0),
 		},
-		startTime: time.Now(),
+		startTime:       time.Now(),
+		killed:          make(map[*exec.Cmd]string),
+		restartRequests: make(chan struct{}, 1),
 	}
 }
 
-// Start starts the process
+// Start starts the process and the worker that carries out restart
+// requests. Stop cancels the build and hooks it is waiting on.
 func (pm *ProcessManager) Start() error {
+	go pm.restartLoop()
+
+	// Nothing to run until the first build succeeds
+	if err := pm.runBuild(pm.stopCtx); err != nil {
+		return nil
+	}
+
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
-	if err := pm.startProcess(); err != nil {
+	if pm.stopped {
+		return nil
+	}
+
+	if err := pm.startProcess(pm.stopCtx); err != nil {
+		if pm.stopCtx.Err() != nil {
+			// Stop was called during the beforeStart hooks
+			return nil
+		}
+		var hookErr *hookError
+		if errors.As(err, &hookErr) {
+			// Let the next file change try again
+			fmt.Printf("%s %v, waiting for file changes\n", utils.Error("Not starting:"), err)
+			return nil
+		}
 		return fmt.Errorf("error starting process: %v", err)
 	}
 
@@ -47,101 +111,119 @@ func (pm *ProcessManager) Start() error {
 }
 
 // startProcess starts the managed process
-func (pm *ProcessManager) startProcess() error {
-	// Determine the runner based on file extension
-	runner := pm.determineRunner()
-	if runner == "" {
-		return fmt.Errorf("unsupported script type: %s", filepath.Ext(pm.scriptPath))
+func (pm *ProcessManager) startProcess(ctx context.Context) error {
+	if err := pm.runBeforeHooks(ctx, "beforeStart", pm.config.Hooks.BeforeStart); err != nil {
+		return err
 	}
 
-	var cmd *exec.Cmd
-
-	// Build command based on runner type
-	if runner == "ts-node" || runner == "tsx" {
-		// For TypeScript files, use npx to ensure we use the local installation
-		args := []string{"-y", runner}
-
-		// Add configured flags if available
-		if pm.config.TSNodeFlags != "" {
-			flags := strings.Split(pm.config.TSNodeFlags, " ")
-			args = append(args, flags...)
-		} else if runner == "ts-node" {
-			// Default ts-node flags if none configured
-			args = append(args, "--esm")
-		}
-
-		// Add the script path
-		args = append(args, pm.scriptPath)
-		cmd = exec.Command("npx", args...)
-	} else {
-		// For JavaScript files, use node directly
-		cmd = exec.Command(runner, pm.scriptPath)
+	cmd, err := pm.buildCommand()
+	if err != nil {
+		return err
 	}
 
 	// Set up command environment
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Env = os.Environ()
+	setProcessGroup(cmd)
 	pm.cmd = cmd
 
 	// Start process
 	if err := pm.cmd.Start(); err != nil {
 		return err
 	}
+	pm.startTime = time.Now()
 
 	// Monitor process in background
+	exited := make(chan struct{})
+	cleanedUp := make(chan struct{})
+	handled := make(chan struct{})
+	pm.exited = exited
+	pm.cleanedUp = cleanedUp
+	pm.handled = handled
+	started := pm.startTime
 	go func() {
-		err := pm.cmd.Wait()
-		pm.handleProcessExit(err)
+		err := cmd.Wait()
+		close(exited)
+		pm.runAfterHooks(context.Background(), "afterExit", pm.currentConfig().Hooks.AfterExit)
+		close(cleanedUp)
+		pm.handleProcessExit(cmd, started, err)
+		close(handled)
 	}()
 
+	if pm.config.MemoryLimit > 0 {
+		go pm.monitorMemory(cmd, exited)
+	}
+	if pm.config.HealthCheck && pm.config.HealthProbe != nil {
+		go pm.monitorHealth(cmd, started, exited)
+	}
+
+	// The hooks run without holding mutex so they cannot hold up exits,
+	// monitors or Stop, which cancels them
+	if hooks := pm.config.Hooks.AfterStart; len(hooks) > 0 {
+		pm.afterStart.Add(1)
+		go func() {
+			defer pm.afterStart.Done()
+			pm.runAfterHooks(pm.stopCtx, "afterStart", hooks)
+		}()
+	}
+
 	return nil
 }
 
-// determineRunner determines which runner to use based on file extension and project setup
-func (pm *ProcessManager) determineRunner() string {
-	ext := strings.ToLower(filepath.Ext(pm.scriptPath))
-	fmt.Println("Running...")
-	switch ext {
-	case ".ts", ".tsx":
-		// Use configured TypeScript runner if specified
-		if pm.config.TypeScriptRunner != "" {
-			return pm.config.TypeScriptRunner
-		}
-
-		// Check for local installations first using npx
-		if err := exec.Command("npx", "-y", "tsx", "--version").Run(); err == nil {
-			return "tsx"
+// buildCommand creates the command for the configured exec line or script
+func (pm *ProcessManager) buildCommand() (*exec.Cmd, error) {
+	// Arbitrary command lines bypass runner detection entirely
+	if pm.config.Exec != "" {
+		args, err := splitCommandLine(pm.config.Exec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exec command: %v", err)
 		}
-		if err := exec.Command("npx", "-y", "ts-node", "--version").Run(); err == nil {
-			return "ts-node"
+		if len(args) == 0 {
+			return nil, fmt.Errorf("exec command is empty")
 		}
+		fmt.Printf("Running %s\n", utils.Command(pm.config.Exec))
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = execDir(pm.config)
+		return cmd, nil
+	}
 
-		// Fallback to checking global installations
-		if _, err := exec.LookPath("tsx"); err == nil {
-			return "tsx"
-		}
-		if _, err := exec.LookPath("ts-node"); err == nil {
-			return "ts-node"
+	// Resolve the runner once and reuse it for every restart
+	if pm.runnerArgs == nil || pm.config.RedetectRunner {
+		args, err := pm.runners.Command(pm.scriptPath)
+		if err != nil {
+			return nil, err
 		}
-		return ""
-	case ".js", ".jsx":
-		return "node"
-	default:
-		return ""
+		pm.runnerArgs = args
+		fmt.Printf("%s %s\n", utils.Dimmed("Runner:"), utils.Command(strings.Join(args, " ")))
+	}
+
+	fmt.Println("Running...")
+	return exec.Command(pm.runnerArgs[0], pm.runnerArgs[1:]...), nil
+}
+
+// execDir is where the exec command runs. Relative paths in a command from
+// the config file are relative to the project, like those of build and
+// hooks; one from the command line or environment runs where quickdev was
+// started, like a shell would run it.
+func execDir(cfg *types.FileWatcherConfig) string {
+	if cfg.Origins["exec"].Layer == config.LayerFile {
+		return cfg.ProjectRoot
 	}
+	return ""
 }
 
 // handleProcessExit handles the process exit
-func (pm *ProcessManager) handleProcessExit(err error) {
+func (pm *ProcessManager) handleProcessExit(cmd *exec.Cmd, started time.Time, err error) {
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
 	// Update restart stats
 	exitTime := time.Now()
-	uptime := exitTime.Sub(pm.startTime)
+	uptime := exitTime.Sub(started)
 	exitCode := 0
 	errorMsg := ""
+	reason := ""
 
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -150,16 +232,31 @@ func (pm *ProcessManager) handleProcessExit(err error) {
 		errorMsg = err.Error()
 	}
 
+	killReason, killed := pm.killed[cmd]
+	delete(pm.killed, cmd)
+
+	switch {
+	case killed:
+		reason = killReason
+	case err != nil:
+		reason = "crashed"
+	default:
+		reason = "exited"
+	}
+
 	// Add to history
 	pm.restartStats.RestartHistory = append(pm.restartStats.RestartHistory, types.RestartHistoryEntry{
 		Time:     exitTime,
 		ExitCode: exitCode,
 		Error:    errorMsg,
 		Duration: uptime,
+		Reason:   reason,
 	})
 
-	// Update stats
-	pm.restartStats.TotalRestarts++
+	// Update stats; the final shutdown is not a restart
+	if !pm.stopped {
+		pm.restartStats.TotalRestarts++
+	}
 	pm.restartStats.LastRestart = exitTime
 	pm.restartStats.LastExitCode = exitCode
 	pm.restartStats.LastErrorMessage = errorMsg
@@ -184,67 +281,392 @@ func (pm *ProcessManager) handleProcessExit(err error) {
 		}
 		pm.restartStats.AverageUptime = total / time.Duration(len(pm.restartStats.RestartHistory))
 	}
+
+	// Exits we caused ourselves need no further handling
+	if pm.stopped || cmd != pm.cmd || killed {
+		return
+	}
+
+	if err == nil {
+		fmt.Printf("%s\n", utils.Dimmed("Process exited cleanly, waiting for file changes"))
+		return
+	}
+
+	pm.handleCrash(cmd, exitCode)
 }
 
-// Restart restarts the process
-func (pm *ProcessManager) Restart() error {
+// handleCrash counts a crash and either schedules an automatic restart or
+// enters the crash loop state once MaxRestarts is reached within the
+// ResetRestartsAfter window
+func (pm *ProcessManager) handleCrash(cmd *exec.Cmd, exitCode int) {
+	if pm.countCrash(fmt.Sprintf("last exit code %d", exitCode)) {
+		return
+	}
+
+	// Back off a little more on every consecutive crash
+	delay := time.Duration(pm.config.RestartDelay) * time.Millisecond * time.Duration(pm.crashCount)
+	if delay > maxCrashRestartDelay {
+		delay = maxCrashRestartDelay
+	}
+
+	fmt.Printf("\n%s exit code %d, restarting in %s\n", utils.Warning("Process crashed:"), exitCode, delay)
+	go pm.autoRestart(cmd, delay)
+}
+
+// countCrash counts an abnormal exit within the ResetRestartsAfter window
+// and reports whether it reached MaxRestarts, in which case the process is
+// left alone until the next file change. detail describes the last exit.
+func (pm *ProcessManager) countCrash(detail string) bool {
+	now := time.Now()
+	window := time.Duration(pm.config.ResetRestartsAfter) * time.Millisecond
+	if pm.crashCount == 0 || (window > 0 && now.Sub(pm.crashWindowStart) > window) {
+		pm.crashCount = 0
+		pm.crashWindowStart = now
+	}
+	pm.crashCount++
+
+	if pm.config.MaxRestarts > 0 && pm.crashCount >= pm.config.MaxRestarts {
+		pm.crashLoop = true
+		fmt.Printf("\n%s %s\n",
+			utils.Error("Crash loop detected, waiting for file changes:"),
+			fmt.Sprintf("%d crashes within %s, %s", pm.crashCount, window, detail))
+		return true
+	}
+	return false
+}
+
+// autoRestart restarts a crashed process unless something else has
+// restarted or stopped it in the meantime
+func (pm *ProcessManager) autoRestart(crashed *exec.Cmd, delay time.Duration) {
+	time.Sleep(delay)
+
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
-	// Stop current process
-	if pm.cmd != nil && pm.cmd.Process != nil {
-		if pm.config.GracefulShutdown {
-			// Send SIGTERM and wait for graceful shutdown
-			if err := pm.cmd.Process.Signal(os.Interrupt); err != nil {
-				pm.cmd.Process.Kill()
-			} else {
-				// Wait for process to exit or timeout
-				done := make(chan error)
-				go func() {
-					done <- pm.cmd.Wait()
-				}()
-
-				select {
-				case <-done:
-					// Process exited gracefully
-				case <-time.After(time.Duration(pm.config.GracefulShutdownTimeout) * time.Second):
-					// Timeout, force kill
-					pm.cmd.Process.Kill()
-				}
+	if pm.stopped || pm.cmd != crashed {
+		return
+	}
+
+	// The leader has exited, but whatever it started may still be running
+	pm.replaceProcess("crashed")
+}
+
+// restartCounted restarts the process for reason, counting the restart
+// like a crash so a process that keeps failing ends up stopped in the
+// crash loop state. The caller holds mutex.
+func (pm *ProcessManager) restartCounted(reason string) {
+	if pm.countCrash(reason) {
+		pm.stopProcess(reason)
+		return
+	}
+
+	fmt.Printf("\n%s %s\n", utils.Warning("Restarting process:"), reason)
+	pm.replaceProcess(reason)
+}
+
+// replaceProcess stops the current process for reason and starts a new
+// one, for the restarts quickdev makes on its own. Stop cancels the
+// beforeStart hooks it runs. The caller holds mutex.
+func (pm *ProcessManager) replaceProcess(reason string) {
+	pm.stopProcess(reason)
+	if err := pm.startProcess(pm.stopCtx); err != nil && pm.stopCtx.Err() == nil {
+		fmt.Printf("%s %v\n", utils.Error("Error restarting process:"), err)
+	}
+}
+
+// stopProcess shuts down the current process group and waits for it to
+// exit, escalating to SIGKILL after GracefulShutdownTimeout. reason is
+// recorded as the exit reason, unless the exit was already handled or the
+// process was already being stopped for another reason.
+func (pm *ProcessManager) stopProcess(reason string) error {
+	if pm.cmd == nil || pm.cmd.Process == nil || pm.exited == nil {
+		return nil
+	}
+
+	cmd := pm.cmd
+	select {
+	case <-pm.handled:
+	default:
+		if _, ok := pm.killed[cmd]; !ok {
+			pm.killed[cmd] = reason
+		}
+	}
+
+	// Let the afterExit hooks finish before anything new starts
+	exited, cleanedUp := pm.exited, pm.cleanedUp
+	defer func() {
+		select {
+		case <-exited:
+			<-cleanedUp
+		default:
+		}
+	}()
+
+	select {
+	case <-pm.exited:
+		// The leader is gone but its children may still hold ports
+		if groupAlive(cmd) {
+			killGroup(cmd)
+		}
+		return nil
+	default:
+	}
+
+	if pm.config.GracefulShutdown {
+		// Send SIGTERM to the whole group and wait for it to exit
+		if err := terminateGroup(cmd); err == nil {
+			deadline := time.After(time.Duration(pm.config.GracefulShutdownTimeout) * time.Second)
+			if pm.waitForGroup(cmd, deadline) {
+				return nil
+			}
+			// Timeout, force kill
+		}
+	}
+
+	if err := killGroup(cmd); err != nil {
+		return err
+	}
+	<-pm.exited
+	// SIGKILL reaches the other members asynchronously, so they can
+	// outlive the leader for a moment
+	pm.waitForGroup(cmd, time.After(killWait))
+	return nil
+}
+
+// waitForGroup waits until the process and every other member of its group
+// have exited, or until the deadline passes
+func (pm *ProcessManager) waitForGroup(cmd *exec.Cmd, deadline <-chan time.Time) bool {
+	select {
+	case <-pm.exited:
+	case <-deadline:
+		return false
+	}
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for groupAlive(cmd) {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestRestart schedules a restart and returns immediately. A restart
+// that is still building, running hooks or waiting out RestartDelay is
+// cancelled, and requests made while one is in progress are coalesced into
+// a single restart that picks up the latest changes.
+func (pm *ProcessManager) RequestRestart() {
+	pm.RequestActions(nil, true)
+}
+
+// RequestActions schedules the commands of run-command rules, followed by
+// a restart if restart is set or a command that succeeded asks for one,
+// and returns immediately. Like restarts, commands still running are
+// cancelled and run again together with the new request.
+func (pm *ProcessManager) RequestActions(commands []types.WatchRule, restart bool) {
+	pm.restartMutex.Lock()
+	pm.pendingCommands = mergeCommands(pm.pendingCommands, commands)
+	pm.pendingRestart = pm.pendingRestart || restart
+	if pm.cancelRestart != nil {
+		pm.cancelRestart()
+	}
+	pm.restartMutex.Unlock()
+
+	select {
+	case pm.restartRequests <- struct{}{}:
+	default:
+		// A request is already pending
+	}
+}
+
+// requeueActions puts back the work a cancelled request did not finish,
+// ahead of what was requested since
+func (pm *ProcessManager) requeueActions(commands []types.WatchRule, restart bool) {
+	pm.restartMutex.Lock()
+	defer pm.restartMutex.Unlock()
+	pm.pendingCommands = mergeCommands(commands, pm.pendingCommands)
+	pm.pendingRestart = pm.pendingRestart || restart
+}
+
+// mergeCommands appends the commands of more to commands, running each
+// command once and restarting afterwards if any rule for it asks to
+func mergeCommands(commands, more []types.WatchRule) []types.WatchRule {
+	merged := append([]types.WatchRule(nil), commands...)
+	for _, rule := range more {
+		found := false
+		for i := range merged {
+			if merged[i].Command == rule.Command {
+				merged[i].Restart = merged[i].Restart || rule.Restart
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, rule)
+		}
+	}
+	return merged
+}
+
+// restartLoop carries out requests one at a time
+func (pm *ProcessManager) restartLoop() {
+	for range pm.restartRequests {
+		ctx, cancel := context.WithCancel(pm.stopCtx)
+		pm.restartMutex.Lock()
+		commands, restart := pm.pendingCommands, pm.pendingRestart
+		pm.pendingCommands, pm.pendingRestart = nil, false
+		pm.cancelRestart = cancel
+		pm.restartMutex.Unlock()
+
+		restarted, err := pm.runActions(ctx, commands, restart)
+
+		pm.restartMutex.Lock()
+		pm.cancelRestart = nil
+		pm.restartMutex.Unlock()
+		cancel()
+
+		switch {
+		case err == nil:
+			if restarted {
+				fmt.Printf("%s\n", utils.Success("Process restarted successfully"))
+			}
+		case errors.Is(err, context.Canceled):
+			if !pm.isStopped() {
+				fmt.Printf("%s\n", utils.Dimmed("Cancelled, newer changes pending"))
 			}
-		} else {
-			// Force kill
-			pm.cmd.Process.Kill()
+		case errors.Is(err, ErrBuildFailed), errors.Is(err, errStopped):
+			// Already reported
+		default:
+			fmt.Printf("%s %v\n", utils.Error("Error restarting process:"), err)
+		}
+	}
+}
+
+// runActions runs rule commands in the project root and then restarts the
+// process if asked to, reporting whether it restarted. Work cut short by
+// cancelling ctx is queued again.
+func (pm *ProcessManager) runActions(ctx context.Context, commands []types.WatchRule, restart bool) (bool, error) {
+	config := pm.currentConfig()
+	for i, rule := range commands {
+		fmt.Printf("%s %s\n", utils.Section("Running:"), utils.Command(rule.Command))
+		err := RunCommand(ctx, rule.Command, config.ProjectRoot, "rule", hookTimeout(config))
+		if ctx.Err() != nil {
+			pm.requeueActions(commands[i:], restart)
+			return false, ctx.Err()
+		}
+		if err != nil {
+			fmt.Printf("%s %v\n", utils.Error("Command failed:"), err)
+			continue
 		}
+		restart = restart || rule.Restart
 	}
 
+	if !restart {
+		return false, nil
+	}
+	if err := pm.restart(ctx); err != nil {
+		if ctx.Err() != nil {
+			pm.requeueActions(nil, true)
+			return false, ctx.Err()
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Restart restarts the process and waits for it to start
+func (pm *ProcessManager) Restart() error {
+	err := pm.restart(context.Background())
+	if errors.Is(err, errStopped) {
+		return nil
+	}
+	return err
+}
+
+// errStopped is returned by restart after Stop has been called
+var errStopped = errors.New("process manager stopped")
+
+// restart builds, stops the current process and starts a new one. Until
+// the old process is stopped, cancelling ctx leaves it running.
+func (pm *ProcessManager) restart(ctx context.Context) error {
+	// Build first so a broken build leaves the running process alone
+	if err := pm.runBuild(ctx); err != nil {
+		return err
+	}
+
+	// A failing beforeRestart hook can keep the current process running
+	if err := pm.runBeforeHooks(ctx, "beforeRestart", pm.currentConfig().Hooks.BeforeRestart); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.stopped {
+		return errStopped
+	}
+
+	// A file change always gets a crashed process going again
+	if pm.crashLoop {
+		fmt.Printf("%s\n", utils.Info("File change detected, leaving crash loop state"))
+	}
+	pm.crashLoop = false
+	pm.crashCount = 0
+
+	// Stop current process
+	pm.stopProcess("file change")
+
 	// Delay before restart if configured
 	if pm.config.RestartDelay > 0 {
-		time.Sleep(time.Duration(pm.config.RestartDelay) * time.Millisecond)
+		select {
+		case <-time.After(time.Duration(pm.config.RestartDelay) * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 
 	// Start new process
-	return pm.startProcess()
+	return pm.startProcess(ctx)
 }
 
-// Stop stops the process
+// Stop stops the process for good and waits until its exit has been
+// recorded in the restart statistics
 func (pm *ProcessManager) Stop() error {
+	// Abandon the start or restart in progress
+	pm.stopAll()
+
 	pm.mutex.Lock()
-	defer pm.mutex.Unlock()
+	pm.stopped = true
+	err := pm.stopProcess("shutdown")
+	handled := pm.handled
+	pm.mutex.Unlock()
 
-	if pm.cmd != nil && pm.cmd.Process != nil {
-		if pm.config.GracefulShutdown {
-			if err := pm.cmd.Process.Signal(os.Interrupt); err != nil {
-				return pm.cmd.Process.Kill()
-			}
-			return pm.cmd.Wait()
-		}
-		return pm.cmd.Process.Kill()
+	if handled != nil {
+		<-handled
 	}
-	return nil
+	pm.afterStart.Wait()
+	return err
+}
+
+// isStopped reports whether Stop has been called
+func (pm *ProcessManager) isStopped() bool {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	return pm.stopped
 }
 
 // GetRestartStats returns the current restart statistics
 func (pm *ProcessManager) GetRestartStats() *types.RestartStats {
-	return pm.restartStats
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	stats := *pm.restartStats
+	stats.RestartHistory = append([]types.RestartHistoryEntry(nil), pm.restartStats.RestartHistory...)
+	return &stats
 }
\ No newline at end of file
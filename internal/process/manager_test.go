@@ -0,0 +1,160 @@
+package process
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"quickdev/internal/config"
+	"quickdev/internal/types"
+)
+
+// waitFor polls cond until it holds, failing the test after a while
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// skipWithoutShell skips tests that run sh
+func skipWithoutShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+}
+
+// inCrashLoop reports whether pm gave up restarting
+func (pm *ProcessManager) inCrashLoop() bool {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	return pm.crashLoop
+}
+
+func TestExecDir(t *testing.T) {
+	tests := []struct {
+		layer string
+		want  string
+	}{
+		{config.LayerFile, "/project"},
+		{config.LayerCLI, ""},
+		{config.LayerEnv, ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		cfg := &types.FileWatcherConfig{
+			Exec:        "go run .",
+			ProjectRoot: "/project",
+			Origins:     map[string]types.ConfigOrigin{"exec": {Layer: tt.layer}},
+		}
+		if got := execDir(cfg); got != tt.want {
+			t.Errorf("exec from %q layer runs in %q, want %q", tt.layer, got, tt.want)
+		}
+	}
+}
+
+func TestCountCrash(t *testing.T) {
+	minute := time.Minute
+	tests := []struct {
+		name        string
+		maxRestarts int
+		window      time.Duration
+		elapsed     []time.Duration // time since the window started, per crash
+		want        []bool
+	}{
+		{"loop at max restarts", 3, minute, []time.Duration{0, 0, 0}, []bool{false, false, true}},
+		{"window expired", 3, minute, []time.Duration{0, 0, 2 * minute, 0}, []bool{false, false, false, false}},
+		{"new window counts again", 2, minute, []time.Duration{0, 2 * minute, 0}, []bool{false, false, true}},
+		{"no window never resets", 3, 0, []time.Duration{0, time.Hour, time.Hour}, []bool{false, false, true}},
+		{"no max never loops", 0, minute, []time.Duration{0, 0, 0, 0}, []bool{false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		pm := NewProcessManager("", &types.FileWatcherConfig{
+			MaxRestarts:        tt.maxRestarts,
+			ResetRestartsAfter: int(tt.window / time.Millisecond),
+		})
+		var got []bool
+		for _, elapsed := range tt.elapsed {
+			if pm.crashCount > 0 {
+				pm.crashWindowStart = time.Now().Add(-elapsed)
+			}
+			got = append(got, pm.countCrash("test"))
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: crash loops = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+		if pm.crashLoop != tt.want[len(tt.want)-1] {
+			t.Errorf("%s: crashLoop = %v", tt.name, pm.crashLoop)
+		}
+	}
+}
+
+func TestCrashLoop(t *testing.T) {
+	skipWithoutShell(t)
+	pm := NewProcessManager("", &types.FileWatcherConfig{
+		Exec:               "sh -c 'exit 3'",
+		MaxRestarts:        3,
+		ResetRestartsAfter: 60000,
+		RestartDelay:       1,
+	})
+	if err := pm.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer pm.Stop()
+
+	waitFor(t, "the crash loop", pm.inCrashLoop)
+
+	// The third crash is not restarted
+	time.Sleep(100 * time.Millisecond)
+	stats := pm.GetRestartStats()
+	if stats.TotalRestarts != 3 || stats.LastExitCode != 3 {
+		t.Errorf("%d restarts, last exit code %d; want 3 and 3", stats.TotalRestarts, stats.LastExitCode)
+	}
+	for _, entry := range stats.RestartHistory {
+		if entry.Reason != "crashed" {
+			t.Errorf("exit reason %q, want crashed", entry.Reason)
+		}
+	}
+}
+
+func TestBuildCommandExec(t *testing.T) {
+	tests := []struct {
+		exec    string
+		args    []string
+		wantErr string
+	}{
+		{"go run ./cmd/api", []string{"go", "run", "./cmd/api"}, ""},
+		{`python3 -c "print('a b')"`, []string{"python3", "-c", "print('a b')"}, ""},
+		{`sh -c 'exit 1`, nil, `invalid exec command: unterminated single quote in "sh -c 'exit 1"`},
+		{"  ", nil, "exec command is empty"},
+	}
+
+	for _, tt := range tests {
+		pm := NewProcessManager("", &types.FileWatcherConfig{Exec: tt.exec})
+		cmd, err := pm.buildCommand()
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("exec %q: error = %v, want %q", tt.exec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("exec %q: unexpected error: %v", tt.exec, err)
+			continue
+		}
+		if !reflect.DeepEqual(cmd.Args, tt.args) {
+			t.Errorf("exec %q: args = %q, want %q", tt.exec, cmd.Args, tt.args)
+		}
+	}
+}
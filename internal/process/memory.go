@@ -0,0 +1,80 @@
+package process
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+
+	"quickdev/internal/utils"
+)
+
+const (
+	// memorySampleInterval is how often the child's memory is sampled
+	memorySampleInterval = 2 * time.Second
+
+	// memoryWarnRatio is the fraction of MemoryLimit that triggers a warning
+	memoryWarnRatio = 0.9
+)
+
+// monitorMemory samples the RSS of a process tree until it exits and
+// enforces MemoryLimit on it
+func (pm *ProcessManager) monitorMemory(cmd *exec.Cmd, exited <-chan struct{}) {
+	limit := uint64(pm.currentConfig().MemoryLimit) * 1024 * 1024
+	warned := false
+
+	ticker := time.NewTicker(memorySampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-exited:
+			return
+		case <-ticker.C:
+		}
+
+		rss, err := processTreeRSS(cmd.Process.Pid)
+		if err != nil {
+			// Unsupported platform or the process is already gone
+			return
+		}
+
+		if rss > limit {
+			pm.handleMemoryLimit(cmd, rss, limit)
+			return
+		}
+
+		if !warned && float64(rss) >= float64(limit)*memoryWarnRatio {
+			warned = true
+			fmt.Printf("%s process is using %s of its %s limit\n",
+				utils.Warning("Memory warning:"), formatMB(rss), formatMB(limit))
+		}
+	}
+}
+
+// handleMemoryLimit restarts or kills a process that exceeded MemoryLimit.
+// Restarts count as crashes towards MaxRestarts.
+func (pm *ProcessManager) handleMemoryLimit(cmd *exec.Cmd, rss, limit uint64) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.stopped || pm.cmd != cmd {
+		return
+	}
+
+	reason := fmt.Sprintf("memory limit exceeded (%s > %s)", formatMB(rss), formatMB(limit))
+
+	if pm.config.MemoryLimitAction == "kill" {
+		fmt.Printf("\n%s %s, waiting for file changes\n", utils.Error("Killing process:"), reason)
+		pm.stopProcess(reason)
+		return
+	}
+
+	// A process that always needs more than the limit would otherwise be
+	// restarted forever
+	pm.restartCounted(reason)
+}
+
+// formatMB formats a byte count in megabytes
+func formatMB(bytes uint64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/1024/1024)
+}
@@ -0,0 +1,92 @@
+package process
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// processTreeRSS returns the resident set size in bytes of a process and
+// all of its descendants, read from /proc
+func processTreeRSS(pid int) (uint64, error) {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return 0, err
+	}
+
+	// Build the parent -> children map of every process we can see
+	children := make(map[int][]int)
+	for _, entry := range entries {
+		child, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if parent, err := readParentPID(child); err == nil {
+			children[parent] = append(children[parent], child)
+		}
+	}
+
+	rss, err := readRSS(pid)
+	if err != nil {
+		return 0, err
+	}
+
+	queue := append([]int(nil), children[pid]...)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		// Descendants may exit while we walk the tree
+		if size, err := readRSS(current); err == nil {
+			rss += size
+		}
+		queue = append(queue, children[current]...)
+	}
+
+	return rss, nil
+}
+
+// readParentPID reads the parent pid from /proc/<pid>/stat
+func readParentPID(pid int) (int, error) {
+	data, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, err
+	}
+
+	// The command name may contain spaces and parentheses, so the fields
+	// we want start after the last ')'
+	stat := string(data)
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+
+	return strconv.Atoi(fields[1])
+}
+
+// readRSS reads the resident set size in bytes from /proc/<pid>/statm
+func readRSS(pid int) (uint64, error) {
+	data, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "statm"))
+	if err != nil {
+		return 0, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed statm for pid %d", pid)
+	}
+
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return pages * uint64(os.Getpagesize()), nil
+}
@@ -0,0 +1,57 @@
+package process
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestReadParentPID(t *testing.T) {
+	got, err := readParentPID(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := os.Getppid(); got != want {
+		t.Errorf("readParentPID = %d, want %d", got, want)
+	}
+
+	if _, err := readParentPID(-1); err == nil {
+		t.Error("readParentPID of a missing process: expected an error")
+	}
+}
+
+func TestProcessTreeRSS(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	child, err := readRSS(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	own, err := readRSS(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child == 0 || own == 0 {
+		t.Fatalf("RSS of the child %d and of the test %d, want both above 0", child, own)
+	}
+
+	// The tree of the test includes the child
+	tree, err := processTreeRSS(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree <= own || tree < child {
+		t.Errorf("tree RSS %d, want more than the test's %d and at least the child's %d", tree, own, child)
+	}
+
+	if _, err := processTreeRSS(-1); err == nil {
+		t.Error("processTreeRSS of a missing process: expected an error")
+	}
+}
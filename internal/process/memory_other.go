@@ -0,0 +1,10 @@
+//go:build !linux
+
+package process
+
+import "errors"
+
+// processTreeRSS is only implemented on Linux
+func processTreeRSS(pid int) (uint64, error) {
+	return 0, errors.New("memory sampling is not supported on this platform")
+}
@@ -0,0 +1,88 @@
+package process
+
+import (
+	"os/exec"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+// currentCmd returns the process pm is running
+func (pm *ProcessManager) currentCmd() *exec.Cmd {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	return pm.cmd
+}
+
+func TestFormatMB(t *testing.T) {
+	tests := []struct {
+		bytes uint64
+		want  string
+	}{
+		{0, "0.0 MB"},
+		{512 * 1024, "0.5 MB"},
+		{500 * 1024 * 1024, "500.0 MB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatMB(tt.bytes); got != tt.want {
+			t.Errorf("formatMB(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestHandleMemoryLimit(t *testing.T) {
+	skipWithoutShell(t)
+	const mb = 1024 * 1024
+	reason := "memory limit exceeded (600.0 MB > 500.0 MB)"
+
+	tests := []struct {
+		action   string
+		restarts []bool // whether each time over the limit restarts
+	}{
+		{"kill", []bool{false}},
+		{"restart", []bool{true, false}},
+	}
+
+	for _, tt := range tests {
+		pm := NewProcessManager("", &types.FileWatcherConfig{
+			Exec:                    "sleep 30",
+			MemoryLimitAction:       tt.action,
+			MaxRestarts:             2,
+			ResetRestartsAfter:      60000,
+			GracefulShutdownTimeout: 1,
+		})
+		if err := pm.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		for i, restarts := range tt.restarts {
+			cmd := pm.currentCmd()
+			pm.handleMemoryLimit(cmd, 600*mb, 500*mb)
+
+			if got := pm.currentCmd() != cmd && pm.isRunning(); got != restarts {
+				t.Errorf("%s, time %d over the limit: restarted = %v, want %v", tt.action, i+1, got, restarts)
+			}
+			if !restarts && pm.isRunning() {
+				t.Errorf("%s, time %d over the limit: process still running", tt.action, i+1)
+			}
+		}
+
+		if tt.action == "restart" && !pm.inCrashLoop() {
+			t.Errorf("%s: not in the crash loop state after %d restarts", tt.action, len(tt.restarts))
+		}
+		pm.Stop()
+
+		// Stop only waits for the exit of the last process to be recorded
+		var history []types.RestartHistoryEntry
+		waitFor(t, "the exits to be recorded", func() bool {
+			history = pm.GetRestartStats().RestartHistory
+			return len(history) == len(tt.restarts)
+		})
+		for _, entry := range history {
+			if entry.Reason != reason {
+				t.Errorf("%s: exit reason %q, want %q", tt.action, entry.Reason, reason)
+			}
+		}
+	}
+}
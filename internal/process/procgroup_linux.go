@@ -0,0 +1,44 @@
+package process
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// hasLiveMember reports whether a process group has a member that is not
+// a zombie, read from /proc
+func hasLiveMember(pgid int) bool {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return true
+	}
+
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+
+		// Fields after the command name: state, ppid, pgrp, ...
+		stat := string(data)
+		end := strings.LastIndex(stat, ")")
+		if end < 0 {
+			continue
+		}
+		fields := strings.Fields(stat[end+1:])
+		if len(fields) < 3 || fields[0] == "Z" {
+			continue
+		}
+		if group, err := strconv.Atoi(fields[2]); err == nil && group == pgid {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package process
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// processState reads the state letter of a process from /proc
+func processState(pid int) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return "", err
+	}
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) == 0 {
+		return "", fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	return fields[0], nil
+}
+
+func TestHasLiveMember(t *testing.T) {
+	if !hasLiveMember(syscall.Getpgrp()) {
+		t.Error("hasLiveMember of the test's own group = false, want true")
+	}
+
+	// An exited child that has not been reaped is a zombie, which does
+	// not keep its group alive
+	cmd := exec.Command("true")
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start true: %v", err)
+	}
+	pid := cmd.Process.Pid
+	waitFor(t, "the child to become a zombie", func() bool {
+		state, err := processState(pid)
+		return err != nil || state == "Z"
+	})
+	if hasLiveMember(pid) {
+		t.Error("hasLiveMember of a group of zombies = true, want false")
+	}
+	if groupAlive(cmd) {
+		t.Error("groupAlive of a group of zombies = true, want false")
+	}
+	cmd.Wait()
+
+	if groupAlive(cmd) {
+		t.Error("groupAlive of a reaped group = true, want false")
+	}
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package process
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group so the
+// whole tree (npx -> node, shells, ...) can be signalled at once
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateGroup asks every process in the command's group to shut down
+func terminateGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// killGroup forcefully kills every process in the command's group
+func killGroup(cmd *exec.Cmd) error {
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
+
+// groupAlive reports whether any process in the command's group is still
+// running. Zombies do not count: when nobody reaps orphaned children (for
+// example when quickdev is PID 1 in a container) they would otherwise keep
+// the group alive until the shutdown timeout.
+func groupAlive(cmd *exec.Cmd) bool {
+	if syscall.Kill(-cmd.Process.Pid, 0) != nil {
+		return false
+	}
+	return hasLiveMember(cmd.Process.Pid)
+}
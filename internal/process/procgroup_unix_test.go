@@ -0,0 +1,51 @@
+//go:build !windows
+
+package process
+
+import (
+	"testing"
+	"time"
+
+	"quickdev/internal/types"
+)
+
+func TestStopProcessGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		exec    string
+		minTime time.Duration
+	}{
+		// npx and shells leave the real work to children
+		{"children", "sh -c 'sleep 30 & sleep 30; wait'", 0},
+		// A group that ignores SIGTERM is killed after the timeout
+		{"ignores SIGTERM", `sh -c 'trap "" TERM; sleep 30 & sleep 30; wait'`, time.Second},
+	}
+
+	for _, tt := range tests {
+		pm := NewProcessManager("", &types.FileWatcherConfig{
+			Exec:                    tt.exec,
+			GracefulShutdown:        true,
+			GracefulShutdownTimeout: 1,
+		})
+		if err := pm.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmd := pm.currentCmd()
+
+		// Wait for the shell to start its children
+		time.Sleep(200 * time.Millisecond)
+
+		start := time.Now()
+		if err := pm.Stop(); err != nil {
+			t.Errorf("%s: Stop: %v", tt.name, err)
+		}
+		elapsed := time.Since(start)
+
+		if groupAlive(cmd) {
+			t.Errorf("%s: process group still alive after Stop", tt.name)
+		}
+		if elapsed < tt.minTime || elapsed > tt.minTime+time.Second {
+			t.Errorf("%s: Stop took %s, want %s to %s", tt.name, elapsed, tt.minTime, tt.minTime+time.Second)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package process
+
+import "quickdev/internal/types"
+
+// restartKeys are the settings that only take effect when the process
+// starts, because they shape its command line or the monitors started
+// with it. Everything else is read when it is used.
+var restartKeys = map[string]bool{
+	"script":              true,
+	"exec":                true,
+	"build":               true,
+	"typescriptRunner":    true,
+	"runners":             true,
+	"tsNodeFlags":         true,
+	"offline":             true,
+	"memoryLimit":         true,
+	"healthCheck":         true,
+	"healthCheckInterval": true,
+	"healthProbe":         true,
+}
+
+// Reconfigure switches to a reloaded configuration and returns the
+// changed settings, by JSON key, that need a restart to take effect. The
+// running process is left alone; restarting it is up to the caller.
+func (pm *ProcessManager) Reconfigure(config *types.FileWatcherConfig, changed []string) []string {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.configMutex.Lock()
+	pm.config = config
+	pm.configMutex.Unlock()
+	pm.scriptPath = config.Script
+	pm.runners = NewRunnerRegistry(config)
+	pm.runnerArgs = nil
+
+	var restart []string
+	for _, key := range changed {
+		if restartKeys[key] {
+			restart = append(restart, key)
+		}
+	}
+	return restart
+}
+
+// currentConfig returns the configuration for code that does not hold
+// mutex, such as builds, hooks and monitors
+func (pm *ProcessManager) currentConfig() *types.FileWatcherConfig {
+	pm.configMutex.RLock()
+	defer pm.configMutex.RUnlock()
+	return pm.config
+}
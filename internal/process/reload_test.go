@@ -0,0 +1,36 @@
+package process
+
+import (
+	"reflect"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+func TestReconfigure(t *testing.T) {
+	tests := []struct {
+		changed []string
+		want    []string
+	}{
+		{nil, nil},
+		{[]string{"debounceMs", "ignore", "hooks", "maxRestarts"}, nil},
+		{[]string{"ignore", "script", "healthProbe"}, []string{"script", "healthProbe"}},
+		{[]string{"exec", "build", "runners", "tsNodeFlags", "offline", "memoryLimit"},
+			[]string{"exec", "build", "runners", "tsNodeFlags", "offline", "memoryLimit"}},
+	}
+
+	for _, tt := range tests {
+		pm := NewProcessManager("/p/app.js", &types.FileWatcherConfig{Script: "/p/app.js"})
+		pm.runnerArgs = []string{"node", "/p/app.js"}
+
+		config := &types.FileWatcherConfig{Script: "/p/server.js"}
+		if got := pm.Reconfigure(config, tt.changed); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("changed %q: restart for %q, want %q", tt.changed, got, tt.want)
+		}
+
+		// The runner is resolved again for the next start
+		if pm.currentConfig() != config || pm.scriptPath != "/p/server.js" || pm.runnerArgs != nil {
+			t.Errorf("changed %q: config %p, script %q, runner %q after reconfiguring", tt.changed, pm.currentConfig(), pm.scriptPath, pm.runnerArgs)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"quickdev/internal/types"
+)
+
+func TestMergeCommands(t *testing.T) {
+	prisma := types.WatchRule{Pattern: "*.prisma", Command: "npx prisma generate"}
+	codegen := types.WatchRule{Pattern: "*.graphql", Command: "npm run codegen"}
+	codegenRestart := types.WatchRule{Pattern: "*.gql", Command: "npm run codegen", Restart: true}
+
+	tests := []struct {
+		commands []types.WatchRule
+		more     []types.WatchRule
+		want     []types.WatchRule
+	}{
+		{nil, nil, nil},
+		{nil, []types.WatchRule{prisma}, []types.WatchRule{prisma}},
+		{[]types.WatchRule{prisma}, []types.WatchRule{codegen}, []types.WatchRule{prisma, codegen}},
+		{[]types.WatchRule{codegen, prisma}, []types.WatchRule{prisma}, []types.WatchRule{codegen, prisma}},
+		{[]types.WatchRule{codegen}, []types.WatchRule{codegenRestart}, []types.WatchRule{{Pattern: "*.graphql", Command: "npm run codegen", Restart: true}}},
+		{[]types.WatchRule{codegenRestart}, []types.WatchRule{codegen}, []types.WatchRule{codegenRestart}},
+	}
+
+	for _, tt := range tests {
+		commands := append([]types.WatchRule(nil), tt.commands...)
+		got := mergeCommands(commands, tt.more)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mergeCommands(%v, %v) = %v, want %v", tt.commands, tt.more, got, tt.want)
+		}
+		if !reflect.DeepEqual(commands, tt.commands) {
+			t.Errorf("mergeCommands(%v, %v) changed its first argument", tt.commands, tt.more)
+		}
+	}
+}
+
+func TestRequeueActions(t *testing.T) {
+	first := types.WatchRule{Command: "first"}
+	second := types.WatchRule{Command: "second"}
+	newer := types.WatchRule{Command: "newer"}
+
+	pm := NewProcessManager("", &types.FileWatcherConfig{})
+	pm.pendingCommands = []types.WatchRule{newer, second}
+
+	// Unfinished work goes ahead of the newer request
+	pm.requeueActions([]types.WatchRule{first, second}, true)
+
+	want := []types.WatchRule{first, second, newer}
+	if !reflect.DeepEqual(pm.pendingCommands, want) || !pm.pendingRestart {
+		t.Errorf("pending = %v and restart %v, want %v and true", pm.pendingCommands, pm.pendingRestart, want)
+	}
+}
+
+func TestRequestRestartCoalesces(t *testing.T) {
+	skipWithoutShell(t)
+
+	dir := t.TempDir()
+	starts := filepath.Join(dir, "starts")
+	pm := NewProcessManager("", &types.FileWatcherConfig{
+		Build:                   "sleep 0.2",
+		Exec:                    "sleep 30",
+		ProjectRoot:             dir,
+		GracefulShutdownTimeout: 1,
+		Hooks:                   types.Hooks{BeforeStart: []string{"echo start >> starts"}},
+	})
+	defer pm.Stop()
+	if err := pm.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each request cancels the build of the one before it
+	for i := 0; i < 5; i++ {
+		pm.RequestRestart()
+		time.Sleep(50 * time.Millisecond)
+	}
+	waitFor(t, "the restart", func() bool {
+		return len(readLines(starts)) >= 2
+	})
+
+	// Give any extra restart time to show up
+	time.Sleep(500 * time.Millisecond)
+	if got := len(readLines(starts)); got != 2 {
+		t.Errorf("started %d times, want once and a single restart", got)
+	}
+}
+
+func TestRequestActionsRunsCommands(t *testing.T) {
+	skipWithoutShell(t)
+
+	dir := t.TempDir()
+	events := filepath.Join(dir, "events")
+	pm := NewProcessManager("", &types.FileWatcherConfig{
+		Exec:                    "sleep 30",
+		ProjectRoot:             dir,
+		GracefulShutdownTimeout: 1,
+		Hooks:                   types.Hooks{BeforeStart: []string{"echo start >> events"}},
+	})
+	defer pm.Stop()
+	if err := pm.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		commands []types.WatchRule
+		restart  bool
+		want     []string
+	}{
+		{[]types.WatchRule{{Command: "echo notify >> events"}}, false,
+			[]string{"notify"}},
+		{[]types.WatchRule{{Command: "echo generate >> events", Restart: true}}, false,
+			[]string{"generate", "start"}},
+		{[]types.WatchRule{{Command: "false", Restart: true}, {Command: "echo lint >> events"}}, false,
+			[]string{"lint"}},
+		{[]types.WatchRule{{Command: "echo generate >> events"}}, true,
+			[]string{"generate", "start"}},
+	}
+
+	for _, tt := range tests {
+		os.WriteFile(events, nil, 0644)
+		pm.RequestActions(tt.commands, tt.restart)
+		waitFor(t, "the actions", func() bool {
+			return len(readLines(events)) >= len(tt.want)
+		})
+		time.Sleep(100 * time.Millisecond)
+		if got := readLines(events); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v with restart %v ran %v, want %v", tt.commands, tt.restart, got, tt.want)
+		}
+	}
+}
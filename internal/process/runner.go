@@ -0,0 +1,218 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"quickdev/internal/types"
+)
+
+// Runner describes how to execute a script with a given tool
+type Runner struct {
+	Name         string // Name used in config, e.g. "tsx"
+	Template     string // Command template, e.g. "tsx {flags} {script}"
+	Package      string // npm package providing the binary, empty for system tools
+	DefaultFlags string // Flags used for {flags} when none are configured
+}
+
+// builtinRunners are the runners known by name
+var builtinRunners = map[string]Runner{
+	types.RunnerNode:   {Name: types.RunnerNode, Template: "node {flags} {script}"},
+	types.RunnerTSX:    {Name: types.RunnerTSX, Template: "tsx {flags} {script}", Package: "tsx"},
+	types.RunnerTSNode: {Name: types.RunnerTSNode, Template: "ts-node {flags} {script}", Package: "ts-node", DefaultFlags: "--esm"},
+	types.RunnerBun:    {Name: types.RunnerBun, Template: "bun run {flags} {script}"},
+	types.RunnerDeno:   {Name: types.RunnerDeno, Template: "deno run -A {flags} {script}"},
+}
+
+// defaultRunners maps extensions to the runners tried, in order, when the
+// config does not choose one
+var defaultRunners = map[string][]string{
+	".js":  {"node"},
+	".jsx": {"node"},
+	".mjs": {"node"},
+	".cjs": {"node"},
+	".ts":  {"tsx", "ts-node"},
+	".tsx": {"tsx", "ts-node"},
+	".mts": {"tsx", "ts-node"},
+	".cts": {"tsx", "ts-node"},
+}
+
+// typeScriptExtensions are the extensions covered by TypeScriptRunner and
+// TSNodeFlags
+var typeScriptExtensions = map[string]bool{
+	".ts":  true,
+	".tsx": true,
+	".mts": true,
+	".cts": true,
+}
+
+// RunnerRegistry resolves the command used to run a script from its
+// extension
+type RunnerRegistry struct {
+	config     *types.FileWatcherConfig
+	extensions map[string][]string
+}
+
+// NewRunnerRegistry creates a registry from the built-in defaults, the
+// TypeScriptRunner setting and the per-extension "runners" config
+func NewRunnerRegistry(config *types.FileWatcherConfig) *RunnerRegistry {
+	extensions := make(map[string][]string, len(defaultRunners))
+	for ext, runners := range defaultRunners {
+		extensions[ext] = runners
+	}
+
+	if config.TypeScriptRunner != "" {
+		for ext := range typeScriptExtensions {
+			extensions[ext] = []string{config.TypeScriptRunner}
+		}
+	}
+
+	for ext, runner := range config.Runners {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extensions[ext] = []string{runner}
+	}
+
+	return &RunnerRegistry{
+		config:     config,
+		extensions: extensions,
+	}
+}
+
+// Command returns the command line that runs scriptPath. Binaries are
+// looked up in node_modules/.bin (walking up from the script), then in
+// PATH; npm-provided runners that are not installed fall back to npx
+// unless the config is offline.
+func (r *RunnerRegistry) Command(scriptPath string) ([]string, error) {
+	ext := strings.ToLower(filepath.Ext(scriptPath))
+	candidates, ok := r.extensions[ext]
+	if !ok {
+		return nil, fmt.Errorf("unsupported script type: %s (map it in the \"runners\" config)", ext)
+	}
+
+	dir := filepath.Dir(scriptPath)
+	for _, name := range candidates {
+		runner := lookupRunner(name)
+		args, err := r.expand(runner, scriptPath, ext)
+		if err != nil {
+			return nil, err
+		}
+		if binary, ok := findBinary(args[0], dir); ok {
+			args[0] = binary
+			return args, nil
+		}
+	}
+
+	// Nothing installed: let npx fetch the first npm-provided runner
+	if !r.config.Offline {
+		for _, name := range candidates {
+			runner := lookupRunner(name)
+			if runner.Package == "" {
+				continue
+			}
+			args, err := r.expand(runner, scriptPath, ext)
+			if err != nil {
+				return nil, err
+			}
+			return append([]string{"npx", "-y"}, args...), nil
+		}
+	}
+
+	if r.config.Offline {
+		return nil, fmt.Errorf("no runner installed for %s scripts, tried %s (offline mode, npx is not used)", ext, strings.Join(candidates, ", "))
+	}
+	return nil, fmt.Errorf("no runner found for %s scripts, tried %s", ext, strings.Join(candidates, ", "))
+}
+
+// expand fills in the runner template for a script
+func (r *RunnerRegistry) expand(runner Runner, scriptPath, ext string) ([]string, error) {
+	words, err := splitCommandLine(runner.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid runner %q: %v", runner.Template, err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("runner for %s scripts is empty", ext)
+	}
+
+	flagLine := runner.DefaultFlags
+	if typeScriptExtensions[ext] && r.config.TSNodeFlags != "" {
+		flagLine = r.config.TSNodeFlags
+	}
+	flags, err := splitCommandLine(flagLine)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flags %q: %v", flagLine, err)
+	}
+
+	var args []string
+	hasScript := false
+	for _, word := range words {
+		if word == "{flags}" {
+			args = append(args, flags...)
+			continue
+		}
+		if strings.Contains(word, "{script}") {
+			hasScript = true
+			word = strings.ReplaceAll(word, "{script}", scriptPath)
+		}
+		args = append(args, word)
+	}
+	if !hasScript {
+		args = append(args, scriptPath)
+	}
+
+	return args, nil
+}
+
+// lookupRunner turns a config value into a runner: either the name of a
+// built-in runner or a command template such as "python3 -u {script}"
+func lookupRunner(value string) Runner {
+	if runner, ok := builtinRunners[value]; ok {
+		return runner
+	}
+	if !strings.Contains(value, "{script}") {
+		value += " {script}"
+	}
+	return Runner{Name: value, Template: value}
+}
+
+// findBinary locates an executable in the nearest node_modules/.bin, then
+// in PATH. Paths containing a separator are used as they are.
+func findBinary(name, dir string) (string, bool) {
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		if _, err := os.Stat(name); err != nil {
+			return "", false
+		}
+		return name, true
+	}
+
+	names := []string{name}
+	if runtime.GOOS == "windows" {
+		names = []string{name + ".cmd", name + ".exe", name}
+	}
+
+	for {
+		for _, candidate := range names {
+			binary := filepath.Join(dir, "node_modules", ".bin", candidate)
+			if info, err := os.Stat(binary); err == nil && !info.IsDir() {
+				return binary, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	if binary, err := exec.LookPath(name); err == nil {
+		return binary, true
+	}
+	return "", false
+}
@@ -0,0 +1,164 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+// installBinary creates an empty executable called name in dir
+func installBinary(t *testing.T, dir, name string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunnerCommand(t *testing.T) {
+	skipWithoutShell(t)
+
+	root := t.TempDir()
+	bin := filepath.Join(root, "node_modules", ".bin")
+	path := filepath.Join(root, "path")
+	t.Setenv("PATH", path)
+	installBinary(t, path, "node")
+	installBinary(t, path, "python3")
+
+	script := filepath.Join(root, "src", "app.ts")
+
+	tests := []struct {
+		name      string
+		installed []string
+		config    types.FileWatcherConfig
+		script    string
+		want      []string
+		wantErr   string
+	}{
+		{"node from PATH", nil, types.FileWatcherConfig{},
+			filepath.Join(root, "src", "app.js"),
+			[]string{filepath.Join(path, "node"), filepath.Join(root, "src", "app.js")}, ""},
+		{"tsx from node_modules", []string{"tsx", "ts-node"}, types.FileWatcherConfig{}, script,
+			[]string{filepath.Join(bin, "tsx"), script}, ""},
+		{"ts-node when tsx is missing", []string{"ts-node"}, types.FileWatcherConfig{}, script,
+			[]string{filepath.Join(bin, "ts-node"), "--esm", script}, ""},
+		{"ts-node flags", []string{"ts-node"}, types.FileWatcherConfig{TSNodeFlags: "--transpile-only"}, script,
+			[]string{filepath.Join(bin, "ts-node"), "--transpile-only", script}, ""},
+		{"chosen TypeScript runner", []string{"tsx", "ts-node"}, types.FileWatcherConfig{TypeScriptRunner: "ts-node"}, script,
+			[]string{filepath.Join(bin, "ts-node"), "--esm", script}, ""},
+		{"npx when nothing is installed", nil, types.FileWatcherConfig{}, script,
+			[]string{"npx", "-y", "tsx", script}, ""},
+		{"offline", nil, types.FileWatcherConfig{Offline: true}, script,
+			nil, "no runner installed for .ts scripts, tried tsx, ts-node (offline mode, npx is not used)"},
+		{"command template", nil, types.FileWatcherConfig{Runners: map[string]string{"PY": "python3 -u {script}"}},
+			filepath.Join(root, "main.py"),
+			[]string{filepath.Join(path, "python3"), "-u", filepath.Join(root, "main.py")}, ""},
+		{"command without a script placeholder", nil, types.FileWatcherConfig{Runners: map[string]string{".py": "python3"}},
+			filepath.Join(root, "main.py"),
+			[]string{filepath.Join(path, "python3"), filepath.Join(root, "main.py")}, ""},
+		{"unsupported extension", nil, types.FileWatcherConfig{}, filepath.Join(root, "main.rb"),
+			nil, `unsupported script type: .rb (map it in the "runners" config)`},
+	}
+
+	for _, tt := range tests {
+		os.RemoveAll(filepath.Join(root, "node_modules"))
+		for _, name := range tt.installed {
+			installBinary(t, bin, name)
+		}
+
+		args, err := NewRunnerRegistry(&tt.config).Command(tt.script)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(args, tt.want) {
+			t.Errorf("%s: command = %q, want %q", tt.name, args, tt.want)
+		}
+	}
+}
+
+func TestFindBinary(t *testing.T) {
+	skipWithoutShell(t)
+
+	root := t.TempDir()
+	t.Setenv("PATH", filepath.Join(root, "path"))
+	nearest := installBinary(t, filepath.Join(root, "app", "node_modules", ".bin"), "tsx")
+	outer := installBinary(t, filepath.Join(root, "node_modules", ".bin"), "ts-node")
+	onPath := installBinary(t, filepath.Join(root, "path"), "node")
+	if err := os.MkdirAll(filepath.Join(root, "node_modules", ".bin", "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	deep := filepath.Join(root, "app", "src", "server")
+	tests := []struct {
+		name   string
+		dir    string
+		want   string
+		wantOk bool
+	}{
+		{"tsx", deep, nearest, true},
+		{"ts-node", deep, outer, true},
+		{"node", deep, onPath, true},
+		{"tsx", root, "", false},
+		{"dir", deep, "", false},
+		{"deno", deep, "", false},
+		{nearest, root, nearest, true},
+		{filepath.Join(root, "missing"), root, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := findBinary(tt.name, tt.dir)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("findBinary(%q, %q) = %q, %v, want %q, %v", tt.name, tt.dir, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestRunnerCached(t *testing.T) {
+	skipWithoutShell(t)
+
+	root := t.TempDir()
+	path := filepath.Join(root, "path")
+	t.Setenv("PATH", path)
+	installBinary(t, path, "node")
+	script := filepath.Join(root, "app.js")
+
+	for _, redetect := range []bool{false, true} {
+		pm := NewProcessManager(script, &types.FileWatcherConfig{RedetectRunner: redetect})
+		if _, err := pm.buildCommand(); err != nil {
+			t.Fatal(err)
+		}
+
+		// A runner installed after the first start only counts when
+		// re-detecting
+		bin := filepath.Join(root, "node_modules", ".bin")
+		os.RemoveAll(bin)
+		installBinary(t, bin, "node")
+		cmd, err := pm.buildCommand()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := filepath.Join(path, "node")
+		if redetect {
+			want = filepath.Join(bin, "node")
+		}
+		if cmd.Path != want {
+			t.Errorf("redetect %v: restart ran %s, want %s", redetect, cmd.Path, want)
+		}
+		os.RemoveAll(bin)
+	}
+}
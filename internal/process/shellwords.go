@@ -0,0 +1,73 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitCommandLine splits a command line into words using POSIX shell
+// quoting rules: whitespace separates words, single quotes are literal,
+// double quotes allow backslash escapes of \, ", $ and `, and a backslash
+// outside quotes escapes the next character. Expansions are not performed.
+func splitCommandLine(line string) ([]string, error) {
+	var words []string
+	var current strings.Builder
+	inWord := false
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("trailing backslash in %q", line)
+			}
+			i++
+			current.WriteRune(runes[i])
+			inWord = true
+
+		case r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated single quote in %q", line)
+			}
+			current.WriteString(string(runes[i+1 : end]))
+			i = end
+			inWord = true
+
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\\\"$`", runes[i+1]) {
+					i++
+				}
+				current.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated double quote in %q", line)
+			}
+			inWord = true
+
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, current.String())
+	}
+
+	return words, nil
+}
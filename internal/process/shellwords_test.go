@@ -0,0 +1,50 @@
+package process
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"go run ./cmd/api", []string{"go", "run", "./cmd/api"}},
+		{"  node\tapp.js \n", []string{"node", "app.js"}},
+		{`python3 -c 'print("hi there")'`, []string{"python3", "-c", `print("hi there")`}},
+		{`echo 'a\b $HOME'`, []string{"echo", `a\b $HOME`}},
+		{`echo "a \"b\" \\ \$x \n"`, []string{"echo", `a "b" \ $x \n`}},
+		{`echo a\ b \'c\'`, []string{"echo", "a b", "'c'"}},
+		{`--flag="some value"`, []string{"--flag=some value"}},
+		{`pre'fix'"suffix"`, []string{"prefixsuffix"}},
+		{`empty '' ""`, []string{"empty", "", ""}},
+		{"日本 語", []string{"日本", "語"}},
+	}
+
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.line)
+		if err != nil {
+			t.Errorf("splitCommandLine(%q): unexpected error: %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCommandLineErrors(t *testing.T) {
+	for _, line := range []string{
+		`echo 'unterminated`,
+		`echo "unterminated`,
+		`echo "escaped quote\"`,
+		`trailing\`,
+	} {
+		if words, err := splitCommandLine(line); err == nil {
+			t.Errorf("splitCommandLine(%q) = %q, want an error", line, words)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"quickdev/internal/config"
+	"quickdev/internal/process"
+	"quickdev/internal/types"
+	"quickdev/internal/utils"
+	"quickdev/internal/watcher"
+)
+
+// errNothingToRun is returned when the configuration names no script or
+// command
+var errNothingToRun = errors.New("nothing to run; pass -script or -exec, set \"script\" in quickdev.config.json or \"main\" in package.json")
+
+// configLoader loads the configuration again the way it was loaded at
+// startup, with the same flags and search directory
+type configLoader struct {
+	defaults  []config.Setting
+	cli       []config.Setting
+	searchDir string
+}
+
+// load resolves the configuration and prepares it like the initial one
+func (l *configLoader) load() (*types.FileWatcherConfig, error) {
+	cfg, err := config.LoadConfig(l.defaults, l.cli, l.searchDir)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Script == "" && cfg.Exec == "" {
+		return nil, errNothingToRun
+	}
+	normalizeWatchPaths(cfg)
+	return cfg, nil
+}
+
+// reload loads the configuration again and applies what changed to the
+// watcher and the process, restarting the process only for settings that
+// need it. A configuration that fails to load leaves everything as it
+// was. reason says what asked for the reload; an explicit reload reports
+// even when nothing changed.
+func (l *configLoader) reload(reason string, explicit bool, current *types.FileWatcherConfig, rules *config.RuleSet, fw *watcher.FileWatcher, pm *process.ProcessManager) (*types.FileWatcherConfig, *config.RuleSet) {
+	cfg, err := l.load()
+	if err != nil {
+		fmt.Printf("\n%s %v\n", utils.Error("Error reloading configuration:"), err)
+		fmt.Println(utils.Dimmed("Keeping the previous configuration"))
+		return current, rules
+	}
+
+	changed := config.Changes(current, cfg)
+	if len(changed) == 0 {
+		// Touching a config file without changing anything stays quiet
+		if explicit {
+			fmt.Printf("\n%s %s\n", utils.Info("Configuration reloaded:"), utils.Dimmed("nothing changed ("+reason+")"))
+		}
+		return current, rules
+	}
+
+	fmt.Printf("\n%s %s %s\n", utils.Info("Configuration reloaded:"), strings.Join(changed, ", "), utils.Dimmed("("+reason+")"))
+
+	if err := fw.Reconfigure(cfg); err != nil {
+		fmt.Printf("%s %v\n", utils.Warning("Warning:"), err)
+	}
+	if restart := pm.Reconfigure(cfg, changed); len(restart) > 0 {
+		fmt.Printf("%s %s changed\n", utils.Warning("Restarting:"), strings.Join(restart, ", "))
+		pm.RequestRestart()
+	}
+
+	return cfg, config.NewRuleSet(cfg.Rules, cfg.ProjectRoot)
+}
+
+// normalizeWatchPaths makes the watch paths absolute, relative to the
+// project root
+func normalizeWatchPaths(cfg *types.FileWatcherConfig) {
+	for i, path := range cfg.WatchPaths {
+		// Skip empty paths
+		if path == "" {
+			continue
+		}
+
+		// Join with project root if path is relative
+		fullPath := path
+		if !filepath.IsAbs(path) {
+			fullPath = filepath.Join(cfg.ProjectRoot, path)
+		}
+
+		// Convert to absolute path
+		absPath, err := filepath.Abs(fullPath)
+		if err == nil {
+			cfg.WatchPaths[i] = absPath
+		} else {
+			fmt.Printf("Error normalizing path %s: %v\n", path, err)
+		}
+	}
+}
@@ -11,9 +11,18 @@ type FileWatcherConfig struct {
 	Enabled                bool          `json:"enabled"`
 	WatchPaths            []string      `json:"watch"`
 	IgnorePaths           []string      `json:"ignore"`
-	IgnorePatterns        []*regexp.Regexp `json:"ignorePatterns"`
+	IgnoreRules           []IgnoreRule  `json:"-"`                // Patterns loaded from ignore files
+	ProjectRoot           string        `json:"-"`                // Directory relative paths and patterns are resolved against
+	ConfigFile            string        `json:"-"`                // Config file that was loaded, empty if none
+	Origins               map[string]ConfigOrigin `json:"-"`    // Where each setting came from, by JSON key
+	ConfigSources         []string      `json:"-"`                // Files whose changes reload the configuration
+	Script                string        `json:"script"`           // Script to run, relative to the config file
+	IgnorePatterns        []string      `json:"ignorePatterns"`   // Regular expressions matched against project-relative paths
+	IgnoreRegexps         []*regexp.Regexp `json:"-"`            // IgnorePatterns, compiled by config.LoadConfig
 	Extensions            []string      `json:"extensions"`
 	DebounceMs            int           `json:"debounceMs"`
+	DebounceMaxWait       int           `json:"debounceMaxWait"`  // Longest a stream of changes can delay a restart, in ms (0 for no limit)
+	DebounceEdge          string        `json:"debounceEdge"`     // "trailing" (default), "leading" or "both"
 	RestartDelay          int           `json:"restartDelay"`
 	MaxRestarts           int           `json:"maxRestarts"`
 	ResetRestartsAfter    int           `json:"resetRestartsAfter"`
@@ -28,15 +37,97 @@ type FileWatcherConfig struct {
 	EnableFileHashing     bool          `json:"enableHashing"`
 	ClearScreen           bool          `json:"clearScreen"`
 	CustomIgnoreFile      string        `json:"ignoreFile"`
-	WatchDotFiles         bool          `json:"watchDotFiles"`
+	UseGitignore          bool          `json:"useGitignore"`     // Also apply .gitignore, .git/info/exclude and the global excludes file
+	WatchDotFiles         bool          `json:"watchDotFiles"`    // Watch paths with a segment starting with "."
+	WatchAlways           []string      `json:"watchAlways"`      // Files watched regardless of extension and dot-file filters
+	Rules                 []WatchRule   `json:"rules"`            // Per-path actions, first match wins
+	Hooks                 Hooks         `json:"hooks"`            // Commands run around the process lifecycle
 	MaxFileSize           int           `json:"maxFileSize"`
 	ExcludeEmptyFiles     bool          `json:"excludeEmptyFiles"`
 	ParallelProcessing    bool          `json:"parallelProcessing"`
 	HealthCheck           bool          `json:"healthCheck"`
 	HealthCheckInterval   int           `json:"healthCheckInterval"`
+	HealthProbe           *HealthProbe  `json:"healthProbe"`      // Checks the running process, nil to disable
 	MemoryLimit           int           `json:"memoryLimit"`
-	TypeScriptRunner      string        `json:"typescriptRunner"` // "tsx" or "ts-node"
+	MemoryLimitAction     string        `json:"memoryLimitAction"` // "restart" (default) or "kill"
+	TypeScriptRunner      string        `json:"typescriptRunner"` // Runner name ("tsx", "ts-node", "bun", "deno") or template
+	Runners               map[string]string `json:"runners"`     // Extension -> runner name or template, e.g. ".py": "python3 -u {script}"
 	TSNodeFlags           string        `json:"tsNodeFlags"`      // Additional flags for ts-node/tsx
+	Exec                  string        `json:"exec"`             // Arbitrary command line to run instead of a script
+	Build                 string        `json:"build"`            // Command run before every (re)start; restarts only if it succeeds
+	RedetectRunner        bool          `json:"redetectRunner"`   // Resolve the runner again on every restart
+	Offline               bool          `json:"offline"`          // Never fall back to "npx -y" for missing runners
+}
+
+// ConfigOrigin records which configuration layer a setting came from
+type ConfigOrigin struct {
+	Layer  string `json:"layer"`  // "default", "file", "env" or "cli"
+	Source string `json:"source"` // Config file, environment variable or flag
+}
+
+// IgnoreRule is a gitignore-style pattern and the directory it is relative to
+type IgnoreRule struct {
+	Pattern string `json:"pattern"`
+	Base    string `json:"base"`
+}
+
+// Built-in runners, usable as TypeScriptRunner and as values of Runners
+const (
+	RunnerNode   = "node"
+	RunnerTSX    = "tsx"
+	RunnerTSNode = "ts-node"
+	RunnerBun    = "bun"
+	RunnerDeno   = "deno"
+)
+
+// RunnerNames lists the built-in runners in alphabetical order
+var RunnerNames = []string{RunnerBun, RunnerDeno, RunnerNode, RunnerTSNode, RunnerTSX}
+
+// Health probe types
+const (
+	ProbeHTTP    = "http"
+	ProbeTCP     = "tcp"
+	ProbeCommand = "command"
+)
+
+// HealthProbe checks that the running process is serving. It is run every
+// HealthCheckInterval seconds, and more often until the process is ready.
+type HealthProbe struct {
+	Type             string `json:"type"`             // One of the Probe values
+	URL              string `json:"url"`              // URL fetched with GET by http probes
+	ExpectStatus     int    `json:"expectStatus"`     // Status expected by http probes, any 2xx or 3xx if 0
+	Address          string `json:"address"`          // host:port dialed by tcp probes
+	Command          string `json:"command"`          // Shell command run by command probes, healthy if it exits 0
+	Timeout          int    `json:"timeout"`          // Milliseconds before a probe fails (default 2000)
+	FailureThreshold int    `json:"failureThreshold"` // Consecutive failures that restart a ready process (default 3)
+	StartTimeout     int    `json:"startTimeout"`     // Milliseconds a process has to become ready before it is restarted (default 30000)
+}
+
+// Hooks are shell commands run in the project root around the process
+// lifecycle. Each list runs in order and stops at the first failure.
+type Hooks struct {
+	BeforeStart    []string `json:"beforeStart"`    // Before every start, including restarts
+	AfterStart     []string `json:"afterStart"`     // After every start
+	BeforeRestart  []string `json:"beforeRestart"`  // Before a file change stops the running process
+	AfterExit      []string `json:"afterExit"`      // After the process exits, for any reason
+	Timeout        int      `json:"timeout"`        // Seconds before a hook is killed (default 60)
+	AbortOnFailure bool     `json:"abortOnFailure"` // Do not (re)start when a before-hook fails
+}
+
+// Watch rule actions
+const (
+	RuleActionRestart    = "restart"
+	RuleActionIgnore     = "ignore"
+	RuleActionRunCommand = "run-command"
+	RuleActionNotifyOnly = "notify-only"
+)
+
+// WatchRule maps a glob pattern to what happens when a matching file changes
+type WatchRule struct {
+	Pattern string `json:"pattern"` // Glob relative to the project root, same syntax as ignore files
+	Action  string `json:"action"`  // One of the RuleAction values, defaults to restart
+	Command string `json:"command"` // Shell command for run-command
+	Restart bool   `json:"restart"` // Restart after run-command succeeds
 }
 
 // FileChangeEvent represents a single file change event
@@ -74,6 +165,7 @@ type RestartHistoryEntry struct {
 	ExitCode  int          `json:"exitCode"`
 	Error     string       `json:"error"`
 	Duration  time.Duration `json:"duration"`
+	Reason    string       `json:"reason"` // Why the process stopped, e.g. "file change" or "memory limit exceeded"
 }
 
 // RestartStats tracks process restart statistics
@@ -114,46 +206,6 @@ type WatcherHealth struct {
 	ErrorCount     int       `json:"errorCount"`
 	LastError      string    `json:"lastError"`
 	LastErrorTime  time.Time `json:"lastErrorTime"`
-}
-
-// ConfigFile represents the quickdev.config.json structure
-type ConfigFile struct {
-	// Core settings
-	Script     string   `json:"script"`
-	Watch      []string `json:"watch"`
-	Ignore     []string `json:"ignore"`
-	Extensions []string `json:"extensions"`
-
-	// Process Management
-	GracefulShutdown      bool `json:"gracefulShutdown"`
-	GracefulShutdownTimeout int `json:"gracefulShutdownTimeout"`
-	MaxRestarts           int  `json:"maxRestarts"`
-	ResetRestartsAfter    int  `json:"resetRestartsAfter"`
-	RestartDelay          int  `json:"restartDelay"`
-
-	// File Watching
-	BatchChanges    bool   `json:"batchChanges"`
-	BatchTimeout    int    `json:"batchTimeout"`
-	EnableHashing   bool   `json:"enableHashing"`
-	UsePolling      bool   `json:"usePolling"`
-	PollingInterval int    `json:"pollingInterval"`
-	FollowSymlinks  bool   `json:"followSymlinks"`
-	WatchDotFiles   bool   `json:"watchDotFiles"`
-	IgnoreFile      string `json:"ignoreFile"`
-
-	// Performance
-	ParallelProcessing bool `json:"parallelProcessing"`
-	MemoryLimit        int  `json:"memoryLimit"`
-	MaxFileSize        int  `json:"maxFileSize"`
-	ExcludeEmptyFiles  bool `json:"excludeEmptyFiles"`
-	DebounceMs         int  `json:"debounceMs"`
-
-	// Monitoring
-	HealthCheck       bool `json:"healthCheck"`
-	HealthCheckInterval int `json:"healthCheckInterval"`
-	ClearScreen       bool `json:"clearScreen"`
-
-	// TypeScript specific
-	TypeScriptRunner string `json:"typescriptRunner"` // "tsx" or "ts-node"
-	TSNodeFlags      string `json:"tsNodeFlags"`      // Additional flags for ts-node
+	Mode           string    `json:"mode"`        // "fsnotify", "polling" or "hybrid"
+	PolledPaths    []string  `json:"polledPaths"` // Subtrees scanned by the poller
 }
\ No newline at end of file
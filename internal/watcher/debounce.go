@@ -0,0 +1,174 @@
+package watcher
+
+import (
+	"sync"
+	"time"
+)
+
+// Debounce edges
+const (
+	edgeTrailing = "trailing"
+	edgeLeading  = "leading"
+	edgeBoth     = "both"
+)
+
+// debouncer groups a stream of triggers into bursts that end after wait
+// without a trigger. It fires at the start of a burst (leading edge), at
+// its end (trailing edge) or both, and at least every maxWait while a
+// burst keeps going, so a constant stream of writes cannot defer it
+// forever.
+type debouncer struct {
+	wait     time.Duration
+	maxWait  time.Duration
+	leading  bool
+	trailing bool
+
+	// flush is called with deliver set when the debouncer fires and with
+	// deliver unset when a burst ends with nothing to fire. It is called
+	// without the mutex held, so it may block.
+	flush func(deliver bool)
+
+	// afterFunc starts the timers; tests replace it with a fake clock
+	afterFunc func(d time.Duration, f func()) timer
+
+	mutex     sync.Mutex
+	waitTimer timer
+	maxTimer  timer
+	active    bool // inside a burst
+	pending   bool // triggered since the last fire
+	burst     int  // counts bursts so stale max-wait timers are ignored
+	triggers  int  // counts triggers so stale wait timers are ignored
+}
+
+// timer is the part of *time.Timer the debouncer uses
+type timer interface {
+	Stop() bool
+}
+
+// newDebouncer creates a debouncer for the given edge, which defaults to
+// trailing
+func newDebouncer(wait, maxWait time.Duration, edge string, flush func(deliver bool)) *debouncer {
+	d := &debouncer{
+		wait:    wait,
+		maxWait: maxWait,
+		flush:   flush,
+		afterFunc: func(d time.Duration, f func()) timer {
+			return time.AfterFunc(d, f)
+		},
+	}
+
+	switch edge {
+	case edgeLeading:
+		d.leading = true
+	case edgeBoth:
+		d.leading = true
+		d.trailing = true
+	default:
+		d.trailing = true
+	}
+
+	return d
+}
+
+// trigger records an event
+func (d *debouncer) trigger() {
+	d.mutex.Lock()
+
+	// Without a wait there is nothing to group
+	if d.wait <= 0 {
+		d.mutex.Unlock()
+		d.flush(true)
+		return
+	}
+
+	fire := false
+	if !d.active {
+		d.active = true
+		if d.leading {
+			fire = true
+		} else {
+			d.pending = true
+		}
+		d.burst++
+		if d.maxWait > 0 {
+			d.startMaxTimer()
+		}
+	} else {
+		d.pending = true
+	}
+
+	if d.waitTimer != nil {
+		d.waitTimer.Stop()
+	}
+	d.triggers++
+	triggers := d.triggers
+	d.waitTimer = d.afterFunc(d.wait, func() {
+		d.waitExpired(triggers)
+	})
+	d.mutex.Unlock()
+
+	if fire {
+		d.flush(true)
+	}
+}
+
+// startMaxTimer arms the max-wait timer for the current burst
+func (d *debouncer) startMaxTimer() {
+	burst := d.burst
+	d.maxTimer = d.afterFunc(d.maxWait, func() {
+		d.maxWaitExpired(burst)
+	})
+}
+
+// waitExpired ends the burst unless another trigger came in meanwhile
+func (d *debouncer) waitExpired(triggers int) {
+	d.mutex.Lock()
+	if !d.active || triggers != d.triggers {
+		d.mutex.Unlock()
+		return
+	}
+
+	deliver := d.trailing && d.pending
+	d.active = false
+	d.pending = false
+	if d.maxTimer != nil {
+		d.maxTimer.Stop()
+		d.maxTimer = nil
+	}
+	d.mutex.Unlock()
+
+	d.flush(deliver)
+}
+
+// maxWaitExpired fires in the middle of a long burst
+func (d *debouncer) maxWaitExpired(burst int) {
+	d.mutex.Lock()
+	if !d.active || burst != d.burst {
+		d.mutex.Unlock()
+		return
+	}
+
+	fire := d.pending
+	d.pending = false
+	d.startMaxTimer()
+	d.mutex.Unlock()
+
+	if fire {
+		d.flush(true)
+	}
+}
+
+// stop cancels the timers without firing
+func (d *debouncer) stop() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.waitTimer != nil {
+		d.waitTimer.Stop()
+	}
+	if d.maxTimer != nil {
+		d.maxTimer.Stop()
+	}
+	d.active = false
+	d.pending = false
+}
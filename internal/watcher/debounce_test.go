@@ -0,0 +1,233 @@
+package watcher
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeClock runs the debouncer's timers when the test advances it, so the
+// tests do not depend on the scheduler. Callbacks run on the test goroutine.
+type fakeClock struct {
+	now    time.Duration
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at      time.Duration
+	f       func()
+	stopped bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	wasActive := !t.stopped
+	t.stopped = true
+	return wasActive
+}
+
+func (c *fakeClock) afterFunc(d time.Duration, f func()) timer {
+	t := &fakeTimer{at: c.now + d, f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// advance moves the clock forward by d, firing the timers that fall due in
+// order. Timers started by the callbacks fire too if they fall due in time.
+func (c *fakeClock) advance(d time.Duration) {
+	end := c.now + d
+	for {
+		var next *fakeTimer
+		for _, t := range c.timers {
+			if !t.stopped && t.at <= end && (next == nil || t.at < next.at) {
+				next = t
+			}
+		}
+		if next == nil {
+			break
+		}
+		c.now = next.at
+		next.stopped = true
+		next.f()
+	}
+	c.now = end
+}
+
+// flushRecorder collects the calls a debouncer makes to flush, with the
+// time of each
+type flushRecorder struct {
+	clock *fakeClock
+	calls []bool
+	times []time.Duration
+}
+
+func (r *flushRecorder) flush(deliver bool) {
+	r.calls = append(r.calls, deliver)
+	r.times = append(r.times, r.clock.now)
+}
+
+// deliveryTimes returns when the calls that fired were made
+func (r *flushRecorder) deliveryTimes() []time.Duration {
+	var times []time.Duration
+	for i, deliver := range r.calls {
+		if deliver {
+			times = append(times, r.times[i])
+		}
+	}
+	return times
+}
+
+// newTestDebouncer creates a debouncer driven by a fake clock
+func newTestDebouncer(wait, maxWait time.Duration, edge string) (*debouncer, *fakeClock, *flushRecorder) {
+	clock := &fakeClock{}
+	r := &flushRecorder{clock: clock}
+	d := newDebouncer(wait, maxWait, edge, r.flush)
+	d.afterFunc = clock.afterFunc
+	return d, clock, r
+}
+
+// burst triggers d count times, every interval
+func burst(d *debouncer, clock *fakeClock, count int, interval time.Duration) {
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			clock.advance(interval)
+		}
+		d.trigger()
+	}
+}
+
+const (
+	testWait     = 50 * time.Millisecond
+	testInterval = 10 * time.Millisecond
+	testSettle   = 4 * testWait
+)
+
+func TestDebouncerEdges(t *testing.T) {
+	tests := []struct {
+		edge        string
+		triggers    int
+		duringBurst []bool
+		afterBurst  []bool
+	}{
+		{edgeTrailing, 1, nil, []bool{true}},
+		{edgeTrailing, 5, nil, []bool{true}},
+		{"", 5, nil, []bool{true}},
+		{edgeLeading, 1, []bool{true}, []bool{true, false}},
+		{edgeLeading, 5, []bool{true}, []bool{true, false}},
+		{edgeBoth, 1, []bool{true}, []bool{true, false}},
+		{edgeBoth, 5, []bool{true}, []bool{true, true}},
+	}
+
+	for _, tt := range tests {
+		d, clock, r := newTestDebouncer(testWait, 0, tt.edge)
+
+		burst(d, clock, tt.triggers, testInterval)
+		if !reflect.DeepEqual(r.calls, tt.duringBurst) {
+			t.Errorf("edge %q, %d triggers: flushes during the burst = %v, want %v", tt.edge, tt.triggers, r.calls, tt.duringBurst)
+		}
+
+		clock.advance(testSettle)
+		if !reflect.DeepEqual(r.calls, tt.afterBurst) {
+			t.Errorf("edge %q, %d triggers: flushes after the burst = %v, want %v", tt.edge, tt.triggers, r.calls, tt.afterBurst)
+		}
+	}
+}
+
+func TestDebouncerTrailingWaitsForQuiet(t *testing.T) {
+	d, clock, r := newTestDebouncer(testWait, 0, edgeTrailing)
+
+	// The last of 5 triggers is at 40ms, so the burst ends at 90ms
+	burst(d, clock, 5, testInterval)
+	clock.advance(testSettle)
+
+	want := []time.Duration{90 * time.Millisecond}
+	if got := r.deliveryTimes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("deliveries at %v, want %v", got, want)
+	}
+}
+
+func TestDebouncerSeparateBursts(t *testing.T) {
+	d, clock, r := newTestDebouncer(testWait, 0, edgeLeading)
+
+	burst(d, clock, 3, testInterval)
+	clock.advance(testSettle)
+	burst(d, clock, 3, testInterval)
+	clock.advance(testSettle)
+
+	want := []bool{true, false, true, false}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("flushes = %v, want %v", r.calls, want)
+	}
+}
+
+func TestDebouncerMaxWait(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		edge    string
+		maxWait time.Duration
+		want    []time.Duration
+	}{
+		// A stream of 350ms never goes quiet for the wait, so without a max
+		// wait only the edges fire, and with one it also fires every 100ms
+		{edgeTrailing, 0, []time.Duration{390 * ms}},
+		{edgeTrailing, 2 * testWait, []time.Duration{100 * ms, 200 * ms, 300 * ms, 390 * ms}},
+		{edgeLeading, 0, []time.Duration{0}},
+		{edgeLeading, 2 * testWait, []time.Duration{0, 100 * ms, 200 * ms, 300 * ms}},
+		{edgeBoth, 2 * testWait, []time.Duration{0, 100 * ms, 200 * ms, 300 * ms, 390 * ms}},
+	}
+
+	for _, tt := range tests {
+		d, clock, r := newTestDebouncer(testWait, tt.maxWait, tt.edge)
+
+		burst(d, clock, 35, testInterval)
+		clock.advance(testSettle)
+
+		if got := r.deliveryTimes(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("edge %q, max wait %s: deliveries at %v, want %v", tt.edge, tt.maxWait, got, tt.want)
+		}
+	}
+}
+
+func TestDebouncerWithoutWait(t *testing.T) {
+	d, clock, r := newTestDebouncer(0, 0, edgeTrailing)
+
+	burst(d, clock, 3, 0)
+
+	want := []bool{true, true, true}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("flushes = %v, want %v", r.calls, want)
+	}
+}
+
+func TestDebouncerStop(t *testing.T) {
+	d, clock, r := newTestDebouncer(testWait, 2*testWait, edgeTrailing)
+
+	burst(d, clock, 3, testInterval)
+	d.stop()
+	clock.advance(testSettle)
+
+	if len(r.calls) != 0 {
+		t.Errorf("flushes after stop = %v, want none", r.calls)
+	}
+}
+
+// TestDebouncerFlushWithoutLock checks that flush can call back into the
+// debouncer, which it could not while the debouncer held its mutex
+func TestDebouncerFlushWithoutLock(t *testing.T) {
+	clock := &fakeClock{}
+	var d *debouncer
+	flushes := 0
+	d = newDebouncer(testWait, 0, edgeLeading, func(deliver bool) {
+		flushes++
+		if flushes == 1 {
+			d.stop()
+		}
+	})
+	d.afterFunc = clock.afterFunc
+
+	d.trigger()
+	clock.advance(testSettle)
+
+	if flushes != 1 {
+		t.Errorf("flushes = %d, want 1", flushes)
+	}
+}
@@ -0,0 +1,106 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestIsWatchLimitError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{syscall.ENOSPC, true},
+		{syscall.EMFILE, true},
+		{os.NewSyscallError("inotify_add_watch", syscall.ENOSPC), true},
+		{fmt.Errorf("adding %s: %w", "src", syscall.EMFILE), true},
+		{syscall.EACCES, false},
+		{errors.New("no space left on device"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isWatchLimitError(tt.err); got != tt.want {
+			t.Errorf("isWatchLimitError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestFallbackToPolling(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "watched/a.js"), "a")
+	writeFile(t, filepath.Join(root, "polled/b.js"), "a")
+	writeFile(t, filepath.Join(root, "polled2/c.js"), "a")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Skipf("fsnotify unavailable: %v", err)
+	}
+	cfg := pollerConfig(root)
+	cfg.UsePolling = false
+	fw := newTestWatcher(cfg)
+	fw.watcher = watcher
+	defer fw.Stop()
+
+	if err := fw.addWatchPath(filepath.Join(root, "watched")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the first subtree handed over reports the fallback
+	limit := os.NewSyscallError("inotify_add_watch", syscall.ENOSPC)
+	for _, dir := range []string{"polled", "polled2"} {
+		if err := fw.fallbackToPolling(filepath.Join(root, dir), limit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(fw.errors) != 1 {
+		t.Errorf("%d errors reported, want 1", len(fw.errors))
+	}
+
+	fw.updateHealth()
+	health := fw.GetHealth()
+	wantPolled := []string{filepath.Join(root, "polled"), filepath.Join(root, "polled2")}
+	if health.Mode != "hybrid" || health.Status != "degraded" || !reflect.DeepEqual(health.PolledPaths, wantPolled) {
+		t.Errorf("health = mode %q, status %q, polled %v; want hybrid, degraded, %v", health.Mode, health.Status, health.PolledPaths, wantPolled)
+	}
+	if health.ErrorCount != 1 || health.WatchedDirs != 3 {
+		t.Errorf("health = %d errors, %d watched dirs; want 1 and 3", health.ErrorCount, health.WatchedDirs)
+	}
+
+	// GetHealth returns a copy
+	health.PolledPaths[0] = "changed"
+	if got := fw.GetHealth().PolledPaths[0]; got != wantPolled[0] {
+		t.Errorf("GetHealth shares PolledPaths with the watcher: got %q", got)
+	}
+
+	// The polled subtrees report changes through the poller
+	writeFile(t, filepath.Join(root, "polled/b.js"), "ab")
+	fw.getPoller().poll()
+	fw.flushPending(true)
+	if got, want := delivered(fw, root), map[string]string{"polled/b.js": "WRITE"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+}
+
+func TestHealthModes(t *testing.T) {
+	root := t.TempDir()
+
+	fw := newTestWatcher(pollerConfig(root))
+	defer fw.Stop()
+	fw.poller = newPoller(fw)
+	if err := fw.addWatchPath(root); err != nil {
+		t.Fatal(err)
+	}
+
+	fw.updateHealth()
+	health := fw.GetHealth()
+	if health.Mode != "polling" || health.Status != "healthy" || health.WatchedDirs != 1 {
+		t.Errorf("health = mode %q, status %q, %d watched dirs; want polling, healthy, 1", health.Mode, health.Status, health.WatchedDirs)
+	}
+}
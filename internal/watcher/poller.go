@@ -0,0 +1,200 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fileState is the snapshot of a single file kept by the poller
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// poller is a stat-based scanner used where filesystem events are not
+// delivered (Docker bind mounts, NFS, some network drives)
+type poller struct {
+	fw       *FileWatcher
+	interval time.Duration
+	roots    []string
+	files    map[string]fileState
+	mutex    sync.Mutex
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newPoller creates a poller scanning at the configured interval
+func newPoller(fw *FileWatcher) *poller {
+	interval := time.Duration(fw.config.PollingInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+
+	return &poller{
+		fw:       fw,
+		interval: interval,
+		files:    make(map[string]fileState),
+		done:     make(chan struct{}),
+	}
+}
+
+// addRoot adds a file or directory tree to the scan and records its
+// initial state without emitting events
+func (p *poller) addRoot(root string) error {
+	if _, err := os.Stat(root); err != nil {
+		return fmt.Errorf("error stating path %s: %v", root, err)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, existing := range p.roots {
+		if existing == root {
+			return nil
+		}
+	}
+	p.roots = append(p.roots, root)
+
+	for path, state := range p.scanRoot(root) {
+		p.files[path] = state
+		if p.fw.config.EnableFileHashing {
+			p.fw.rememberHash(path)
+		}
+	}
+
+	return nil
+}
+
+// run scans the roots on every tick until the poller is stopped
+func (p *poller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.poll()
+		}
+	}
+}
+
+// stop terminates the scan loop
+func (p *poller) stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// poll rescans every root and emits events for the differences with the
+// previous snapshot
+func (p *poller) poll() {
+	p.fw.stateMutex.RLock()
+	p.mutex.Lock()
+	current := make(map[string]fileState, len(p.files))
+	for _, root := range p.roots {
+		for path, state := range p.scanRoot(root) {
+			current[path] = state
+		}
+	}
+
+	var events []fsnotify.Event
+	for path, state := range current {
+		previous, existed := p.files[path]
+		switch {
+		case !existed:
+			events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Create})
+		case !state.modTime.Equal(previous.modTime) || state.size != previous.size:
+			events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Write})
+		}
+	}
+	for path := range p.files {
+		if _, exists := current[path]; !exists {
+			events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Remove})
+		}
+	}
+
+	p.files = current
+	p.mutex.Unlock()
+	p.fw.stateMutex.RUnlock()
+
+	for _, event := range events {
+		p.fw.handleEvent(event)
+	}
+}
+
+// scanRoot walks a root and returns the state of every non-ignored file
+func (p *poller) scanRoot(root string) map[string]fileState {
+	files := make(map[string]fileState)
+
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Files can disappear between readdir and stat; skip them
+			return nil
+		}
+
+		if p.fw.shouldIgnore(path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.IsDir() {
+			files[path] = fileState{
+				modTime: info.ModTime(),
+				size:    info.Size(),
+			}
+		}
+		return nil
+	})
+
+	return files
+}
+
+// removeRoot stops scanning a root
+func (p *poller) removeRoot(root string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for i, existing := range p.roots {
+		if existing == root {
+			p.roots = append(p.roots[:i], p.roots[i+1:]...)
+			break
+		}
+	}
+	for path := range p.files {
+		if path == root || strings.HasPrefix(path, root+string(filepath.Separator)) {
+			delete(p.files, path)
+		}
+	}
+}
+
+// resync rescans the roots without emitting events, so files that the
+// ignore rules now include are not reported as created
+func (p *poller) resync() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	files := make(map[string]fileState, len(p.files))
+	for _, root := range p.roots {
+		for path, state := range p.scanRoot(root) {
+			files[path] = state
+		}
+	}
+	p.files = files
+}
+
+// rootList returns the roots being polled
+func (p *poller) rootList() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string(nil), p.roots...)
+}
@@ -0,0 +1,153 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"quickdev/internal/types"
+)
+
+// writeFile creates path and its directories with data
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// pollerConfig watches the .js files under root, ignoring node_modules
+func pollerConfig(root string) *types.FileWatcherConfig {
+	return &types.FileWatcherConfig{
+		ProjectRoot:     root,
+		WatchPaths:      []string{root},
+		IgnorePaths:     []string{"node_modules"},
+		Extensions:      []string{".js"},
+		PollingInterval: 100,
+		UsePolling:      true,
+	}
+}
+
+// delivered drains the changes the watcher delivered, by path relative to
+// root
+func delivered(fw *FileWatcher, root string) map[string]string {
+	changes := make(map[string]string)
+	for len(fw.changes) > 0 {
+		event := <-fw.changes
+		rel, _ := filepath.Rel(root, event.Path)
+		changes[filepath.ToSlash(rel)] = event.Operation
+	}
+	return changes
+}
+
+func TestPollerDiff(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"keep.js", "edit.js", "gone.js", "src/deep.js", "node_modules/lib.js"} {
+		writeFile(t, filepath.Join(root, name), "a")
+	}
+
+	fw := newTestWatcher(pollerConfig(root))
+	defer fw.Stop()
+	p := newPoller(fw)
+	fw.poller = p
+	if err := p.addRoot(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// The initial scan is the baseline, not a change
+	p.poll()
+	if got := delivered(fw, root); len(got) != 0 {
+		t.Fatalf("changes after the initial scan = %v, want none", got)
+	}
+
+	writeFile(t, filepath.Join(root, "edit.js"), "ab")
+	writeFile(t, filepath.Join(root, "new.js"), "a")
+	writeFile(t, filepath.Join(root, "src/deeper/new.js"), "a")
+	writeFile(t, filepath.Join(root, "node_modules/other.js"), "a")
+	writeFile(t, filepath.Join(root, "notes.txt"), "a")
+	if err := os.Remove(filepath.Join(root, "gone.js")); err != nil {
+		t.Fatal(err)
+	}
+
+	p.poll()
+	fw.flushPending(true)
+	want := map[string]string{
+		"edit.js":           "WRITE",
+		"new.js":            "CREATE",
+		"src/deeper/new.js": "CREATE",
+		"gone.js":           "REMOVE",
+	}
+	if got := delivered(fw, root); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+
+	// Nothing changed since the last scan
+	p.poll()
+	fw.flushPending(true)
+	if got := delivered(fw, root); len(got) != 0 {
+		t.Errorf("changes after a quiet scan = %v, want none", got)
+	}
+}
+
+func TestPollerRoots(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a/one.js"), "a")
+	writeFile(t, filepath.Join(root, "b/two.js"), "a")
+
+	fw := newTestWatcher(pollerConfig(root))
+	defer fw.Stop()
+	p := newPoller(fw)
+	fw.poller = p
+	for _, dir := range []string{"a", "b", "a"} {
+		if err := p.addRoot(filepath.Join(root, dir)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.addRoot(filepath.Join(root, "missing")); err == nil {
+		t.Error("addRoot of a missing path: expected an error")
+	}
+
+	want := []string{filepath.Join(root, "a"), filepath.Join(root, "b")}
+	if got := p.rootList(); !reflect.DeepEqual(got, want) {
+		t.Errorf("roots = %v, want %v", got, want)
+	}
+
+	// Files under a removed root are forgotten, not reported as removed
+	p.removeRoot(filepath.Join(root, "a"))
+	writeFile(t, filepath.Join(root, "a/one.js"), "ab")
+	writeFile(t, filepath.Join(root, "b/two.js"), "ab")
+	p.poll()
+	fw.flushPending(true)
+	if got, want := delivered(fw, root), map[string]string{"b/two.js": "WRITE"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+}
+
+func TestPollerResync(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "node_modules/lib.js"), "a")
+
+	fw := newTestWatcher(pollerConfig(root))
+	defer fw.Stop()
+	p := newPoller(fw)
+	fw.poller = p
+	if err := p.addRoot(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files that a new config no longer ignores are not new
+	cfg := pollerConfig(root)
+	cfg.IgnorePaths = nil
+	if err := fw.Reconfigure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	p.poll()
+	fw.flushPending(true)
+	if got := delivered(fw, root); len(got) != 0 {
+		t.Errorf("changes after resync = %v, want none", got)
+	}
+}
@@ -0,0 +1,217 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"quickdev/internal/config"
+	"quickdev/internal/types"
+)
+
+// sourceInterval is how often the config sources are checked. They are a
+// handful of files, so a stat loop is cheap and, unlike watching their
+// directories, works the same with polling and with editors that save by
+// replacing the file.
+const sourceInterval = 500 * time.Millisecond
+
+// watchSources sends a config source to the reload channel when it is
+// created, changed or removed, once it has stopped changing
+func (fw *FileWatcher) watchSources() {
+	ticker := time.NewTicker(sourceInterval)
+	defer ticker.Stop()
+
+	sources := fw.configSources()
+	states := statSources(sources)
+	changed := ""
+
+	for {
+		select {
+		case <-fw.done:
+			return
+		case <-ticker.C:
+		}
+
+		// A reload can switch to other sources; start over from them
+		if current := fw.configSources(); !equalPaths(current, sources) {
+			sources = current
+			states = statSources(sources)
+			changed = ""
+			continue
+		}
+
+		current := statSources(sources)
+		if path := changedSource(sources, states, current); path != "" {
+			// Wait for the next tick so a save in several writes reloads once
+			states = current
+			changed = path
+			continue
+		}
+
+		if changed != "" {
+			select {
+			case fw.reloads <- changed:
+			default:
+			}
+			changed = ""
+		}
+	}
+}
+
+// configSources returns the files whose changes reload the configuration
+func (fw *FileWatcher) configSources() []string {
+	fw.stateMutex.RLock()
+	defer fw.stateMutex.RUnlock()
+	return fw.config.ConfigSources
+}
+
+// isConfigSource reports whether path is a config source handled by
+// reloading. package.json also lists the dependencies, so it keeps its
+// usual handling.
+func (fw *FileWatcher) isConfigSource(path string) bool {
+	if filepath.Base(path) == config.PackageFileName {
+		return false
+	}
+	for _, source := range fw.config.ConfigSources {
+		if source == path {
+			return true
+		}
+	}
+	return false
+}
+
+// statSources records the state of each source; missing files have the
+// zero state so creating one counts as a change
+func statSources(sources []string) map[string]fileState {
+	states := make(map[string]fileState, len(sources))
+	for _, source := range sources {
+		if info, err := os.Stat(source); err == nil {
+			states[source] = fileState{modTime: info.ModTime(), size: info.Size()}
+		} else {
+			states[source] = fileState{}
+		}
+	}
+	return states
+}
+
+// changedSource returns the first source whose state differs between two
+// snapshots, or "" if none does
+func changedSource(sources []string, before, after map[string]fileState) string {
+	for _, source := range sources {
+		if a, b := before[source], after[source]; !a.modTime.Equal(b.modTime) || a.size != b.size {
+			return source
+		}
+	}
+	return ""
+}
+
+// equalPaths compares two lists of paths
+func equalPaths(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Reconfigure applies a reloaded configuration in place: it rebuilds the
+// ignore matchers and rules, stops watching directories that are now
+// ignored or outside the watch paths and starts watching the ones that
+// are newly included. Switching between events and polling needs a new
+// watcher, so usePolling changes are reported as an error and otherwise
+// ignored.
+func (fw *FileWatcher) Reconfigure(cfg *types.FileWatcherConfig) error {
+	fw.stateMutex.Lock()
+	defer fw.stateMutex.Unlock()
+
+	old := fw.config
+	fw.batchMutex.Lock()
+	fw.config = cfg
+	fw.batching = cfg.BatchChanges
+	fw.batchMutex.Unlock()
+	fw.ignore = newIgnoreMatcher(cfg)
+	fw.always = newWatchAlwaysMatcher(cfg)
+	fw.rules = config.NewRuleSet(cfg.Rules, cfg.ProjectRoot)
+
+	if debounceWait(cfg) != debounceWait(old) || cfg.DebounceMaxWait != old.DebounceMaxWait || cfg.DebounceEdge != old.DebounceEdge {
+		// Changes already pending go out with the next trigger
+		fw.debounce.stop()
+		fw.debounce = newDebouncer(debounceWait(cfg), time.Duration(cfg.DebounceMaxWait)*time.Millisecond, cfg.DebounceEdge, fw.flushPending)
+	}
+
+	// Drop the watches that are no longer wanted
+	if fw.watcher != nil {
+		for _, dir := range fw.watcher.WatchList() {
+			if !fw.inWatchPaths(dir) || fw.ignoredTree(dir) {
+				fw.watcher.Remove(dir)
+			}
+		}
+	}
+	if p := fw.getPoller(); p != nil {
+		for _, root := range p.rootList() {
+			if !fw.inWatchPaths(root) {
+				p.removeRoot(root)
+			}
+		}
+	}
+
+	// Walk the watch paths again; directories already watched are kept
+	for _, path := range cfg.WatchPaths {
+		if err := fw.addWatchPath(path); err != nil {
+			fw.errors <- fmt.Errorf("error adding watch path %s: %v", path, err)
+		}
+	}
+	if p := fw.getPoller(); p != nil {
+		p.resync()
+	}
+
+	if cfg.UsePolling != old.UsePolling {
+		return fmt.Errorf("usePolling only changes when quickdev is restarted")
+	}
+	return nil
+}
+
+// inWatchPaths reports whether path is a watch path or lies inside one.
+// For a watch path that is a file, its directory counts.
+func (fw *FileWatcher) inWatchPaths(path string) bool {
+	for _, root := range fw.config.WatchPaths {
+		if root == "" {
+			continue
+		}
+		if info, err := os.Stat(root); err == nil && !info.IsDir() {
+			root = filepath.Dir(root)
+		}
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredTree reports whether dir or a directory above it, within the
+// watch paths, is ignored
+func (fw *FileWatcher) ignoredTree(dir string) bool {
+	for fw.inWatchPaths(dir) {
+		if fw.shouldIgnore(dir, true) {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return false
+}
+
+// GetReloadChannel returns the channel receiving config sources that
+// changed
+func (fw *FileWatcher) GetReloadChannel() <-chan string {
+	return fw.reloads
+}
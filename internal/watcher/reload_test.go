@@ -0,0 +1,156 @@
+package watcher
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"quickdev/internal/types"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchList returns the directories fw watches, relative to root
+func watchList(fw *FileWatcher, root string) []string {
+	var dirs []string
+	for _, dir := range fw.watcher.WatchList() {
+		rel, _ := filepath.Rel(root, dir)
+		dirs = append(dirs, filepath.ToSlash(rel))
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+func TestReconfigureWatches(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"src/a.js", "src/lib/b.js", "dist/c.js", "other/d.js"} {
+		writeFile(t, filepath.Join(root, name), "a")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Skipf("fsnotify unavailable: %v", err)
+	}
+	cfg := &types.FileWatcherConfig{ProjectRoot: root, WatchPaths: []string{root}, Extensions: []string{".js"}}
+	fw := newTestWatcher(cfg)
+	fw.watcher = watcher
+	defer fw.Stop()
+	if err := fw.addWatchPath(root); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		watch  []string
+		ignore []string
+		want   []string
+	}{
+		{[]string{"."}, []string{"dist"}, []string{".", "other", "src", "src/lib"}},
+		{[]string{"src"}, []string{"dist"}, []string{"src", "src/lib"}},
+		{[]string{"src"}, []string{"lib"}, []string{"src"}},
+		{[]string{"."}, nil, []string{".", "dist", "other", "src", "src/lib"}},
+	}
+
+	for i, step := range steps {
+		next := *cfg
+		next.WatchPaths = nil
+		for _, path := range step.watch {
+			next.WatchPaths = append(next.WatchPaths, filepath.Join(root, path))
+		}
+		next.IgnorePaths = step.ignore
+		if err := fw.Reconfigure(&next); err != nil {
+			t.Fatal(err)
+		}
+		if got := watchList(fw, root); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("step %d, watch %q and ignore %q: watching %q, want %q", i+1, step.watch, step.ignore, got, step.want)
+		}
+	}
+}
+
+func TestReconfigureSettings(t *testing.T) {
+	cfg := &types.FileWatcherConfig{DebounceMs: 50}
+	fw := newTestWatcher(cfg)
+	defer fw.Stop()
+
+	tests := []struct {
+		change       func(cfg *types.FileWatcherConfig)
+		wantDebounce bool // whether the debouncer is replaced
+		wantErr      bool
+	}{
+		{func(cfg *types.FileWatcherConfig) { cfg.Extensions = []string{".ts"} }, false, false},
+		{func(cfg *types.FileWatcherConfig) { cfg.DebounceMs = 100 }, true, false},
+		{func(cfg *types.FileWatcherConfig) { cfg.DebounceEdge = edgeLeading }, true, false},
+		{func(cfg *types.FileWatcherConfig) { cfg.DebounceMaxWait = 500 }, true, false},
+		{func(cfg *types.FileWatcherConfig) { cfg.BatchChanges = true }, false, false},
+		{func(cfg *types.FileWatcherConfig) { cfg.UsePolling = true }, false, true},
+	}
+
+	for i, tt := range tests {
+		next := *fw.config
+		tt.change(&next)
+		debounce := fw.currentDebouncer()
+
+		err := fw.Reconfigure(&next)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("change %d: error = %v, want an error: %v", i+1, err, tt.wantErr)
+		}
+		if replaced := fw.currentDebouncer() != debounce; replaced != tt.wantDebounce {
+			t.Errorf("change %d: debouncer replaced = %v, want %v", i+1, replaced, tt.wantDebounce)
+		}
+		if fw.config != &next || fw.batching != next.BatchChanges {
+			t.Errorf("change %d: config not switched", i+1)
+		}
+	}
+}
+
+func TestChangedSource(t *testing.T) {
+	now := time.Now()
+	sources := []string{"/p/quickdev.config.json", "/p/.quickdevignore"}
+	before := map[string]fileState{
+		sources[0]: {modTime: now, size: 10},
+		sources[1]: {},
+	}
+
+	tests := []struct {
+		name  string
+		after map[string]fileState
+		want  string
+	}{
+		{"unchanged", map[string]fileState{sources[0]: {modTime: now, size: 10}, sources[1]: {}}, ""},
+		{"modified", map[string]fileState{sources[0]: {modTime: now.Add(time.Second), size: 10}, sources[1]: {}}, sources[0]},
+		{"resized", map[string]fileState{sources[0]: {modTime: now, size: 11}, sources[1]: {}}, sources[0]},
+		{"created", map[string]fileState{sources[0]: {modTime: now, size: 10}, sources[1]: {modTime: now, size: 1}}, sources[1]},
+		{"removed", map[string]fileState{sources[0]: {}, sources[1]: {}}, sources[0]},
+	}
+
+	for _, tt := range tests {
+		if got := changedSource(sources, before, tt.after); got != tt.want {
+			t.Errorf("%s: changed source %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsConfigSource(t *testing.T) {
+	fw := newTestWatcher(&types.FileWatcherConfig{
+		ConfigSources: []string{"/p/quickdev.config.yaml", "/p/.quickdevignore", "/p/package.json"},
+	})
+	defer fw.Stop()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/p/quickdev.config.yaml", true},
+		{"/p/.quickdevignore", true},
+		// package.json changes also restart, as dependencies may have changed
+		{"/p/package.json", false},
+		{"/p/src/quickdev.config.yaml", false},
+	}
+
+	for _, tt := range tests {
+		if got := fw.isConfigSource(tt.path); got != tt.want {
+			t.Errorf("isConfigSource(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -2,15 +2,19 @@ package watcher
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"quickdev/internal/config"
 	"quickdev/internal/types"
 
 	"github.com/fsnotify/fsnotify"
@@ -19,25 +23,47 @@ import (
 // FileWatcher represents the main file watcher instance
 type FileWatcher struct {
 	config         *types.FileWatcherConfig
+	ignore         *config.IgnoreMatcher
+	always         *config.IgnoreMatcher // Matches WatchAlways entries
+	rules          *config.RuleSet
+	stateMutex     sync.RWMutex          // Held by Reconfigure while it swaps the above
 	watcher        *fsnotify.Watcher
+	poller         *poller
+	pollMutex      sync.Mutex
+	running        bool
+	fellBack       bool
 	fileHashes     map[string]string
 	hashMutex      sync.RWMutex
 	changes        chan types.FileEvent
+	batches        chan types.BatchChangeEvent
 	errors         chan error
-	batchTimer     *time.Timer
+	reloads        chan string
+	done           chan struct{}
+	stopOnce       sync.Once
+	debounce       *debouncer
+	batching       bool // BatchChanges, guarded by batchMutex
+	batchStart     time.Time
 	batchedChanges map[string]types.FileEvent
 	batchMutex     sync.Mutex
 	health         *types.WatcherHealth
+	healthMutex    sync.Mutex
 	startTime      time.Time
 }
 
 // NewFileWatcher creates a new file watcher instance
-func NewFileWatcher(config *types.FileWatcherConfig) *FileWatcher {
-	return &FileWatcher{
-		config:         config,
+func NewFileWatcher(cfg *types.FileWatcherConfig) *FileWatcher {
+	fw := &FileWatcher{
+		config:         cfg,
+		ignore:         newIgnoreMatcher(cfg),
+		always:         newWatchAlwaysMatcher(cfg),
+		rules:          config.NewRuleSet(cfg.Rules, cfg.ProjectRoot),
 		fileHashes:     make(map[string]string),
 		changes:        make(chan types.FileEvent, 100),
+		batches:        make(chan types.BatchChangeEvent, 10),
 		errors:         make(chan error, 100),
+		reloads:        make(chan string, 1),
+		done:           make(chan struct{}),
+		batching:       cfg.BatchChanges,
 		batchedChanges: make(map[string]types.FileEvent),
 		health: &types.WatcherHealth{
 			Status:    "starting",
@@ -45,15 +71,62 @@ func NewFileWatcher(config *types.FileWatcherConfig) *FileWatcher {
 		},
 		startTime: time.Now(),
 	}
+	fw.debounce = newDebouncer(debounceWait(cfg), time.Duration(cfg.DebounceMaxWait)*time.Millisecond, cfg.DebounceEdge, fw.flushPending)
+	return fw
+}
+
+// debounceWait is how long the watcher waits for changes to settle: the
+// debounce time, or the batch timeout when batching and that is longer
+func debounceWait(cfg *types.FileWatcherConfig) time.Duration {
+	wait := cfg.DebounceMs
+	if cfg.BatchChanges && cfg.BatchTimeout > wait {
+		wait = cfg.BatchTimeout
+	}
+	return time.Duration(wait) * time.Millisecond
+}
+
+// newIgnoreMatcher builds the matcher for the ignore paths, which are
+// relative to the project root, and the rules loaded from ignore files
+func newIgnoreMatcher(cfg *types.FileWatcherConfig) *config.IgnoreMatcher {
+	var rules []types.IgnoreRule
+	for _, pattern := range cfg.IgnorePaths {
+		if pattern != "" {
+			rules = append(rules, types.IgnoreRule{Pattern: pattern, Base: cfg.ProjectRoot})
+		}
+	}
+	rules = append(rules, cfg.IgnoreRules...)
+	return config.NewIgnoreMatcher(rules)
+}
+
+// newWatchAlwaysMatcher builds the matcher for the watchAlways entries,
+// which use the same pattern syntax as ignore paths
+func newWatchAlwaysMatcher(cfg *types.FileWatcherConfig) *config.IgnoreMatcher {
+	var rules []types.IgnoreRule
+	for _, pattern := range cfg.WatchAlways {
+		if pattern != "" {
+			rules = append(rules, types.IgnoreRule{Pattern: pattern, Base: cfg.ProjectRoot})
+		}
+	}
+	return config.NewIgnoreMatcher(rules)
 }
 
 // Start begins watching for file changes
 func (fw *FileWatcher) Start() error {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return fmt.Errorf("error creating watcher: %v", err)
+	if fw.config.UsePolling {
+		fw.poller = newPoller(fw)
+	} else {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			if !isWatchLimitError(err) {
+				return fmt.Errorf("error creating watcher: %v", err)
+			}
+			// Out of inotify instances, poll everything instead
+			fw.errors <- fmt.Errorf("cannot create filesystem watcher (%v), falling back to polling", err)
+			fw.fellBack = true
+			fw.poller = newPoller(fw)
+		}
+		fw.watcher = watcher
 	}
-	fw.watcher = watcher
 
 	// Add watch paths
 	for _, path := range fw.config.WatchPaths {
@@ -68,13 +141,29 @@ func (fw *FileWatcher) Start() error {
 	}
 
 	// Start watching for events
-	go fw.watchEvents()
+	fw.pollMutex.Lock()
+	fw.running = true
+	if fw.poller != nil {
+		go fw.poller.run()
+	}
+	fw.pollMutex.Unlock()
+	if fw.watcher != nil {
+		go fw.watchEvents()
+	}
+	go fw.watchSources()
 
 	return nil
 }
 
 // Stop gracefully stops the file watcher
 func (fw *FileWatcher) Stop() error {
+	fw.stopOnce.Do(func() {
+		close(fw.done)
+	})
+	fw.currentDebouncer().stop()
+	if p := fw.getPoller(); p != nil {
+		p.stop()
+	}
 	if fw.watcher != nil {
 		return fw.watcher.Close()
 	}
@@ -90,6 +179,11 @@ func (fw *FileWatcher) addWatchPath(path string) error {
 	}
 	path = absPath
 
+	// The poller scans whole trees itself
+	if fw.watcher == nil {
+		return fw.getPoller().addRoot(path)
+	}
+
 	// Check if path exists
 	info, err := os.Stat(path)
 	if err != nil {
@@ -105,7 +199,7 @@ func (fw *FileWatcher) addWatchPath(path string) error {
 			}
 
 			// Skip if path should be ignored
-			if fw.shouldIgnore(subpath) {
+			if fw.shouldIgnore(subpath, info.IsDir()) {
 				if info.IsDir() {
 					// fmt.Printf("Ignoring directory: %s\n", subpath)
 					return filepath.SkipDir
@@ -116,7 +210,14 @@ func (fw *FileWatcher) addWatchPath(path string) error {
 			// Add directory to watcher
 			if info.IsDir() {
 				if err := fw.watcher.Add(subpath); err != nil {
-					return fmt.Errorf("error watching directory %s: %v", subpath, err)
+					if !isWatchLimitError(err) {
+						return fmt.Errorf("error watching directory %s: %v", subpath, err)
+					}
+					// Keep the directories registered so far and poll the rest of this subtree
+					if err := fw.fallbackToPolling(subpath, err); err != nil {
+						return err
+					}
+					return filepath.SkipDir
 				}
 				// fmt.Printf("Watching directory: %s\n", subpath)
 			} else if fw.hasValidExtension(subpath) {
@@ -125,11 +226,7 @@ func (fw *FileWatcher) addWatchPath(path string) error {
 
 			// Calculate initial hash for file if enabled
 			if !info.IsDir() && fw.config.EnableFileHashing {
-				if hash, err := fw.calculateFileHash(subpath); err == nil {
-					fw.hashMutex.Lock()
-					fw.fileHashes[subpath] = hash
-					fw.hashMutex.Unlock()
-				}
+				fw.rememberHash(subpath)
 			}
 
 			return nil
@@ -139,7 +236,48 @@ func (fw *FileWatcher) addWatchPath(path string) error {
 	// If it's a file, just watch its directory
 	dir := filepath.Dir(path)
 	// fmt.Printf("Adding parent directory to watch: %s\n", dir)
-	return fw.watcher.Add(dir)
+	if err := fw.watcher.Add(dir); err != nil {
+		if !isWatchLimitError(err) {
+			return err
+		}
+		return fw.fallbackToPolling(path, err)
+	}
+	return nil
+}
+
+// fallbackToPolling hands a subtree that could not be registered with
+// fsnotify over to the poller, creating and starting it on first use
+func (fw *FileWatcher) fallbackToPolling(path string, cause error) error {
+	fw.pollMutex.Lock()
+	if fw.poller == nil {
+		fw.poller = newPoller(fw)
+		if fw.running {
+			go fw.poller.run()
+		}
+	}
+	p := fw.poller
+	firstFallback := !fw.fellBack
+	fw.fellBack = true
+	fw.pollMutex.Unlock()
+
+	if firstFallback {
+		fw.errors <- fmt.Errorf("filesystem watch limit reached (%v), polling remaining directories starting with %s", cause, path)
+	}
+
+	return p.addRoot(path)
+}
+
+// getPoller returns the poller, if any
+func (fw *FileWatcher) getPoller() *poller {
+	fw.pollMutex.Lock()
+	defer fw.pollMutex.Unlock()
+	return fw.poller
+}
+
+// isWatchLimitError reports whether err means the kernel ran out of
+// inotify watches or instances
+func isWatchLimitError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE)
 }
 
 // watchEvents implements the actual file watching logic
@@ -163,78 +301,193 @@ func (fw *FileWatcher) watchEvents() {
 
 // handleEvent processes a file change event
 func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
-	// Skip if path should be ignored
-	if fw.shouldIgnore(event.Name) {
-		return
+	// Queued without stateMutex, which queueEvent takes to find the debouncer
+	if fileEvent, ok := fw.acceptEvent(event); ok {
+		fw.queueEvent(fileEvent)
 	}
+}
 
-	// Skip if file extension doesn't match
-	if !fw.hasValidExtension(event.Name) {
-		return
+// acceptEvent filters an event and converts it into a change, reporting
+// false when it is ignored
+func (fw *FileWatcher) acceptEvent(event fsnotify.Event) (types.FileEvent, bool) {
+	fw.stateMutex.RLock()
+	defer fw.stateMutex.RUnlock()
+
+	// Config sources reload the configuration instead
+	if fw.isConfigSource(event.Name) {
+		return types.FileEvent{}, false
 	}
 
-	// Handle directory events
 	info, err := os.Stat(event.Name)
-	if err == nil && info.IsDir() {
-		if event.Op&fsnotify.Create == fsnotify.Create {
+	isDir := err == nil && info.IsDir()
+
+	// Skip if path should be ignored
+	if fw.shouldIgnore(event.Name, isDir) {
+		return types.FileEvent{}, false
+	}
+
+	// Handle directory events
+	if isDir {
+		if event.Op&fsnotify.Create == fsnotify.Create && fw.watcher != nil {
 			fw.addWatchPath(event.Name)
 		}
-		return
+		return types.FileEvent{}, false
+	}
+
+	// Skip if file extension doesn't match, unless always watched or
+	// named by a rule
+	if !fw.hasValidExtension(event.Name) && !fw.isWatchAlways(event.Name) && !fw.hasRule(event.Name) {
+		return types.FileEvent{}, false
 	}
 
 	// Check if file content actually changed
 	if fw.config.EnableFileHashing && event.Op&fsnotify.Write == fsnotify.Write {
 		if !fw.hasFileChanged(event.Name) {
-			return
+			return types.FileEvent{}, false
 		}
 	}
 
 	// Create file event
-	fileEvent := types.FileEvent{
+	return types.FileEvent{
 		Path:      event.Name,
 		Operation: event.Op.String(),
 		Time:      time.Now(),
-	}
+	}, true
+}
 
-	// Handle batching
-	if fw.config.BatchChanges {
-		fw.batchEvent(fileEvent)
-	} else {
-		fw.changes <- fileEvent
+// queueEvent adds a change to the pending set and lets the debouncer
+// decide when to deliver it
+func (fw *FileWatcher) queueEvent(event types.FileEvent) {
+	fw.batchMutex.Lock()
+	if len(fw.batchedChanges) == 0 {
+		fw.batchStart = event.Time
 	}
+	fw.batchedChanges[event.Path] = event
+	fw.batchMutex.Unlock()
+
+	fw.currentDebouncer().trigger()
 }
 
-// batchEvent handles batched file changes
-func (fw *FileWatcher) batchEvent(event types.FileEvent) {
-	fw.batchMutex.Lock()
-	defer fw.batchMutex.Unlock()
+// currentDebouncer returns the debouncer, which Reconfigure replaces when
+// the debounce settings change
+func (fw *FileWatcher) currentDebouncer() *debouncer {
+	fw.stateMutex.RLock()
+	defer fw.stateMutex.RUnlock()
+	return fw.debounce
+}
 
-	// Add event to batch
-	fw.batchedChanges[event.Path] = event
+// flushPending delivers the pending changes, as one batch or as one event
+// per path, and clears them. They are taken under batchMutex and sent
+// after releasing it, so a reader that falls behind only holds up the
+// delivery and not queueEvent or Reconfigure.
+func (fw *FileWatcher) flushPending(deliver bool) {
+	fw.batchMutex.Lock()
+	if !deliver || len(fw.batchedChanges) == 0 {
+		fw.batchedChanges = make(map[string]types.FileEvent)
+		fw.batchMutex.Unlock()
+		return
+	}
 
-	// Reset or start timer
-	if fw.batchTimer != nil {
-		fw.batchTimer.Reset(time.Duration(fw.config.BatchTimeout) * time.Millisecond)
+	var batch types.BatchChangeEvent
+	var events []types.FileEvent
+	batching := fw.batching
+	if batching {
+		batch = fw.buildBatch()
 	} else {
-		fw.batchTimer = time.AfterFunc(time.Duration(fw.config.BatchTimeout)*time.Millisecond, func() {
-			fw.flushBatchedChanges()
-		})
+		events = make([]types.FileEvent, 0, len(fw.batchedChanges))
+		for _, event := range fw.batchedChanges {
+			events = append(events, event)
+		}
+	}
+
+	// Clear batch
+	fw.batchedChanges = make(map[string]types.FileEvent)
+	fw.batchMutex.Unlock()
+
+	if batching {
+		select {
+		case fw.batches <- batch:
+		case <-fw.done:
+		}
+		return
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Time.Equal(events[j].Time) {
+			return events[i].Time.Before(events[j].Time)
+		}
+		return events[i].Path < events[j].Path
+	})
+	for _, event := range events {
+		select {
+		case fw.changes <- event:
+		case <-fw.done:
+			return
+		}
 	}
 }
 
-// flushBatchedChanges handles a batch of changes
-func (fw *FileWatcher) flushBatchedChanges() {
-	fw.batchMutex.Lock()
-	defer fw.batchMutex.Unlock()
+// buildBatch converts the pending changes into a single batch event
+func (fw *FileWatcher) buildBatch() types.BatchChangeEvent {
+	now := time.Now()
+	changes := make([]types.FileChangeEvent, 0, len(fw.batchedChanges))
 
-	// Send all batched changes
 	for _, event := range fw.batchedChanges {
-		fw.changes <- event
+		change := types.FileChangeEvent{
+			Type:         event.Operation,
+			Filename:     filepath.Base(event.Path),
+			FullPath:     event.Path,
+			RelativePath: fw.relativePath(event.Path),
+			Timestamp:    event.Time,
+		}
+
+		if info, err := os.Stat(event.Path); err == nil {
+			change.Size = info.Size()
+			change.IsDirectory = info.IsDir()
+			change.Stats = info
+		}
+
+		fw.hashMutex.RLock()
+		change.Hash = fw.fileHashes[event.Path]
+		fw.hashMutex.RUnlock()
+
+		changes = append(changes, change)
 	}
 
-	// Clear batch
-	fw.batchedChanges = make(map[string]types.FileEvent)
-	fw.batchTimer = nil
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].FullPath < changes[j].FullPath
+	})
+
+	return types.BatchChangeEvent{
+		Changes:    changes,
+		TotalFiles: len(changes),
+		Timestamp:  now,
+		Duration:   now.Sub(fw.batchStart),
+	}
+}
+
+// relativePath returns path relative to the watch path containing it
+func (fw *FileWatcher) relativePath(path string) string {
+	for _, root := range fw.config.WatchPaths {
+		if root == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// projectRelativePath returns path relative to the project root, slash
+// separated, or the absolute path when it lies outside the project
+func (fw *FileWatcher) projectRelativePath(path string) string {
+	if fw.config.ProjectRoot != "" {
+		if rel, err := filepath.Rel(fw.config.ProjectRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
 }
 
 // hasFileChanged checks if a file has changed by comparing hashes
@@ -258,6 +511,15 @@ func (fw *FileWatcher) hasFileChanged(path string) bool {
 	return false
 }
 
+// rememberHash records the current hash of a file as its baseline
+func (fw *FileWatcher) rememberHash(path string) {
+	if hash, err := fw.calculateFileHash(path); err == nil {
+		fw.hashMutex.Lock()
+		fw.fileHashes[path] = hash
+		fw.hashMutex.Unlock()
+	}
+}
+
 // calculateFileHash calculates the hash of a file
 func (fw *FileWatcher) calculateFileHash(path string) (string, error) {
 	data, err := ioutil.ReadFile(path)
@@ -270,42 +532,76 @@ func (fw *FileWatcher) calculateFileHash(path string) (string, error) {
 }
 
 // shouldIgnore checks if a path should be ignored
-func (fw *FileWatcher) shouldIgnore(path string) bool {
-	// Convert path to forward slashes for consistency
-	path = filepath.ToSlash(path)
-	path = strings.TrimPrefix(path, "./")
-
-	// Check against ignore patterns
-	for _, pattern := range fw.config.IgnorePaths {
-		// Convert pattern to forward slashes and trim ./ prefix
-		pattern = filepath.ToSlash(pattern)
-		pattern = strings.TrimPrefix(pattern, "./")
-
-		// Try exact match first
-		if path == pattern {
-			return true
+func (fw *FileWatcher) shouldIgnore(path string, isDir bool) bool {
+	// Check against gitignore-style ignore rules
+	if fw.ignore.Match(path, isDir) {
+		return true
+	}
+
+	// Check against ignorePatterns regular expressions
+	if len(fw.config.IgnoreRegexps) > 0 {
+		rel := fw.projectRelativePath(path)
+		if isDir {
+			rel += "/"
 		}
+		for _, re := range fw.config.IgnoreRegexps {
+			if re.MatchString(rel) {
+				return true
+			}
+		}
+	}
 
-		// Try glob match
-		if matched, _ := filepath.Match(pattern, path); matched {
-			return true
+	// Check dot files
+	if !fw.config.WatchDotFiles && fw.isDotPath(path) {
+		if isDir {
+			return !fw.containsWatchAlways(path)
 		}
+		return !fw.isWatchAlways(path)
+	}
 
-		// Try contains match (for node_modules etc)
-		if strings.Contains(path, "/"+pattern+"/") || strings.HasSuffix(path, "/"+pattern) {
-			return true
+	return false
+}
+
+// isDotPath reports whether the basename, or any directory between the
+// project root and path, starts with a dot
+func (fw *FileWatcher) isDotPath(path string) bool {
+	rel := filepath.Base(path)
+	if fw.config.ProjectRoot != "" {
+		if r, err := filepath.Rel(fw.config.ProjectRoot, path); err == nil && !strings.HasPrefix(r, "..") {
+			rel = r
 		}
 	}
 
-	// Check dot files
-	if !fw.config.WatchDotFiles && strings.Contains(filepath.Base(path), ".") {
-		// Allow specific extensions even if they start with dot
-		if fw.hasValidExtension(path) {
-			return false
+	for _, segment := range strings.Split(filepath.ToSlash(rel), "/") {
+		if len(segment) > 1 && segment[0] == '.' && segment != ".." {
+			return true
 		}
-		return true
 	}
+	return false
+}
 
+// isWatchAlways reports whether path matches a watchAlways entry
+func (fw *FileWatcher) isWatchAlways(path string) bool {
+	return fw.always.Match(path, false)
+}
+
+// hasRule reports whether a rule other than ignore matches path
+func (fw *FileWatcher) hasRule(path string) bool {
+	rule, ok := fw.rules.Match(path)
+	return ok && rule.Action != types.RuleActionIgnore
+}
+
+// containsWatchAlways reports whether a watchAlways entry names a file
+// inside dir, so that dot directories holding one are still walked
+func (fw *FileWatcher) containsWatchAlways(dir string) bool {
+	prefix := fw.projectRelativePath(dir) + "/"
+	for _, entry := range fw.config.WatchAlways {
+		entry = strings.TrimPrefix(filepath.ToSlash(entry), "./")
+		entry = strings.TrimPrefix(entry, "/")
+		if strings.HasPrefix(entry, prefix) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -337,34 +633,70 @@ func (fw *FileWatcher) monitorHealth() {
 
 // updateHealth performs a health check
 func (fw *FileWatcher) updateHealth() {
-	fw.health.LastCheck = time.Now()
-	fw.health.Status = "healthy"
+	fw.healthMutex.Lock()
+	health := *fw.health
+	fw.healthMutex.Unlock()
+
+	health.LastCheck = time.Now()
+	health.Status = "healthy"
 
 	// Count watched directories
 	watchedDirs := 0
 	if fw.watcher != nil {
 		watchedDirs = len(fw.watcher.WatchList())
 	}
-	fw.health.WatchedDirs = watchedDirs
+	fw.pollMutex.Lock()
+	p, fellBack := fw.poller, fw.fellBack
+	fw.pollMutex.Unlock()
+	health.Mode = "fsnotify"
+	health.PolledPaths = nil
+	if p != nil {
+		health.PolledPaths = p.rootList()
+		watchedDirs += len(health.PolledPaths)
+		if fw.watcher != nil {
+			health.Mode = "hybrid"
+		} else {
+			health.Mode = "polling"
+		}
+	}
+	health.WatchedDirs = watchedDirs
 
 	// Count files being watched
+	fw.hashMutex.RLock()
 	fileCount := len(fw.fileHashes)
-	fw.health.FileCount = fileCount
+	fw.hashMutex.RUnlock()
+	health.FileCount = fileCount
 
 	// Get memory stats
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
-	fw.health.MemoryUsage = m.Alloc
+	health.MemoryUsage = m.Alloc
 
 	// Update error count
 	select {
 	case err := <-fw.errors:
-		fw.health.ErrorCount++
-		fw.health.LastError = err.Error()
-		fw.health.LastErrorTime = time.Now()
-		fw.health.Status = "degraded"
+		health.ErrorCount++
+		health.LastError = err.Error()
+		health.LastErrorTime = time.Now()
+		health.Status = "degraded"
 	default:
 	}
+	if fellBack && health.Status == "healthy" {
+		health.Status = "degraded"
+	}
+
+	fw.healthMutex.Lock()
+	*fw.health = health
+	fw.healthMutex.Unlock()
+}
+
+// GetHealth returns a copy of the result of the last health check
+func (fw *FileWatcher) GetHealth() types.WatcherHealth {
+	fw.healthMutex.Lock()
+	defer fw.healthMutex.Unlock()
+	health := *fw.health
+	health.PolledPaths = append([]string(nil), fw.health.PolledPaths...)
+	return health
 }
 
 // GetChangeChannel returns the channel for file change events
@@ -372,6 +704,11 @@ func (fw *FileWatcher) GetChangeChannel() <-chan types.FileEvent {
 	return fw.changes
 }
 
+// GetBatchChannel returns the channel for batched file changes
+func (fw *FileWatcher) GetBatchChannel() <-chan types.BatchChangeEvent {
+	return fw.batches
+}
+
 // GetErrorChannel returns the channel for errors
 func (fw *FileWatcher) GetErrorChannel() <-chan error {
 	return fw.errors
@@ -0,0 +1,231 @@
+package watcher
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"quickdev/internal/types"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// newTestWatcher creates a watcher whose debouncer never fires on its own
+func newTestWatcher(cfg *types.FileWatcherConfig) *FileWatcher {
+	fw := NewFileWatcher(cfg)
+	fw.debounce.afterFunc = (&fakeClock{}).afterFunc
+	return fw
+}
+
+func TestFlushPending(t *testing.T) {
+	start := time.Now()
+	events := []types.FileEvent{
+		{Path: "/p/b.js", Operation: "WRITE", Time: start},
+		{Path: "/p/a.js", Operation: "WRITE", Time: start.Add(time.Millisecond)},
+		{Path: "/p/b.js", Operation: "REMOVE", Time: start.Add(2 * time.Millisecond)},
+		{Path: "/p/c.js", Operation: "CREATE", Time: start.Add(2 * time.Millisecond)},
+	}
+
+	t.Run("one event per path", func(t *testing.T) {
+		fw := newTestWatcher(&types.FileWatcherConfig{DebounceMs: 50})
+		defer fw.Stop()
+		for _, event := range events {
+			fw.queueEvent(event)
+		}
+		fw.flushPending(true)
+
+		var got []types.FileEvent
+		for len(fw.changes) > 0 {
+			got = append(got, <-fw.changes)
+		}
+		want := []types.FileEvent{events[1], events[2], events[3]}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("delivered %v, want %v", got, want)
+		}
+	})
+
+	t.Run("one batch", func(t *testing.T) {
+		fw := newTestWatcher(&types.FileWatcherConfig{DebounceMs: 50, BatchChanges: true})
+		defer fw.Stop()
+		for _, event := range events {
+			fw.queueEvent(event)
+		}
+		fw.flushPending(true)
+
+		if len(fw.changes) != 0 || len(fw.batches) != 1 {
+			t.Fatalf("delivered %d events and %d batches, want one batch", len(fw.changes), len(fw.batches))
+		}
+		batch := <-fw.batches
+		var paths []string
+		for _, change := range batch.Changes {
+			paths = append(paths, change.FullPath+" "+change.Type)
+		}
+		want := []string{"/p/a.js WRITE", "/p/b.js REMOVE", "/p/c.js CREATE"}
+		if !reflect.DeepEqual(paths, want) || batch.TotalFiles != 3 {
+			t.Errorf("batch of %d = %v, want %v", batch.TotalFiles, paths, want)
+		}
+	})
+
+	t.Run("discarded", func(t *testing.T) {
+		fw := newTestWatcher(&types.FileWatcherConfig{DebounceMs: 50})
+		defer fw.Stop()
+		fw.queueEvent(events[0])
+		fw.flushPending(false)
+		fw.flushPending(true)
+
+		if len(fw.changes) != 0 {
+			t.Errorf("delivered %d events after discarding them, want none", len(fw.changes))
+		}
+	})
+
+	t.Run("stopped while the reader is behind", func(t *testing.T) {
+		fw := newTestWatcher(&types.FileWatcherConfig{DebounceMs: 50})
+		fw.changes = make(chan types.FileEvent)
+		fw.queueEvent(events[0])
+
+		done := make(chan struct{})
+		go func() {
+			fw.flushPending(true)
+			close(done)
+		}()
+		fw.Stop()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("flushPending still blocked after Stop")
+		}
+	})
+}
+
+func TestShouldIgnorePatterns(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "project")
+	fw := newTestWatcher(&types.FileWatcherConfig{
+		ProjectRoot: root,
+		IgnoreRegexps: []*regexp.Regexp{
+			regexp.MustCompile(`\.spec\.ts$`),
+			regexp.MustCompile(`^src/generated/`),
+		},
+	})
+	defer fw.Stop()
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"src/app.spec.ts", false, true},
+		{"src/app.ts", false, false},
+		{"src/generated", true, true},
+		{"src/generated/types.ts", false, true},
+		{"lib/src/generated/types.ts", false, false},
+		{"src", true, false},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(root, filepath.FromSlash(tt.path))
+		if got := fw.shouldIgnore(path, tt.isDir); got != tt.want {
+			t.Errorf("shouldIgnore(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAcceptEventDotFiles(t *testing.T) {
+	root := t.TempDir()
+	files := []string{"app.js", ".eslintrc.js", ".github/ci.js", ".env", ".env.local", ".env.test", "Makefile", "notes.txt", "config/.secrets/key.json"}
+	for _, name := range files {
+		writeFile(t, filepath.Join(root, filepath.FromSlash(name)), "a")
+	}
+
+	tests := []struct {
+		path          string
+		watchDotFiles bool
+		want          bool
+	}{
+		{"app.js", false, true},
+		{".eslintrc.js", false, false},
+		{".github/ci.js", false, false},
+		{".env", false, true},
+		{".env.local", false, true},
+		{".env.test", false, false},
+		{"config/.secrets/key.json", false, true},
+		{"Makefile", false, false},
+		{"notes.txt", false, false},
+
+		{".eslintrc.js", true, true},
+		{".github/ci.js", true, true},
+		{".env", true, true},
+		{".env.test", true, false},
+		{"Makefile", true, false},
+	}
+
+	for _, tt := range tests {
+		fw := newTestWatcher(&types.FileWatcherConfig{
+			ProjectRoot:   root,
+			Extensions:    []string{".js"},
+			WatchDotFiles: tt.watchDotFiles,
+			WatchAlways:   []string{".env", ".env.local", "config/.secrets/key.json"},
+		})
+		path := filepath.Join(root, filepath.FromSlash(tt.path))
+		_, got := fw.acceptEvent(fsnotify.Event{Name: path, Op: fsnotify.Write})
+		if got != tt.want {
+			t.Errorf("%s with watchDotFiles %v: accepted = %v, want %v", tt.path, tt.watchDotFiles, got, tt.want)
+		}
+		fw.Stop()
+	}
+}
+
+func TestShouldIgnoreDotDirectories(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "project")
+	fw := newTestWatcher(&types.FileWatcherConfig{
+		ProjectRoot: root,
+		WatchAlways: []string{"./config/.secrets/key.json"},
+	})
+	defer fw.Stop()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{".git", true},
+		{"src/.cache", true},
+		{"config/.secrets", false},
+		{"config", false},
+		{"src", false},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(root, filepath.FromSlash(tt.path))
+		if got := fw.shouldIgnore(path, true); got != tt.want {
+			t.Errorf("shouldIgnore(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIsDotPath(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "project")
+	fw := newTestWatcher(&types.FileWatcherConfig{ProjectRoot: root})
+	defer fw.Stop()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(root, ".env"), true},
+		{filepath.Join(root, "src", ".hidden", "app.js"), true},
+		{filepath.Join(root, "src", "app.test.js"), false},
+		{filepath.Join(root, "Makefile"), false},
+		{root, false},
+		// Outside the project only the basename counts
+		{filepath.Join(string(filepath.Separator), ".config", "app.js"), false},
+		{filepath.Join(string(filepath.Separator), "other", ".env"), true},
+	}
+
+	for _, tt := range tests {
+		if got := fw.isDotPath(tt.path); got != tt.want {
+			t.Errorf("isDotPath(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}